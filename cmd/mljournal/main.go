@@ -0,0 +1,57 @@
+// Command mljournal inspects a journal written by the bulk operations of the
+// mlapi package.
+//
+// Usage:
+//
+//	mljournal [-json] <journal>
+//
+// It prints the number of items that succeeded and the latest error of every
+// item that has not.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/grafana/machine-learning-go-client/mlapi"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the summary as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-json] <journal>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	entries, err := mlapi.ReadJournal(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	summary := mlapi.SummarizeJournal(entries)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Printf("%d succeeded, %d failed\n", summary.Succeeded, len(summary.Failed))
+	for _, e := range summary.Failed {
+		fmt.Printf("%s %s %s (%s): %s\n", e.Resource, e.Op, e.Key, e.Time.Format("2006-01-02T15:04:05Z07:00"), e.Error)
+	}
+	if len(summary.Failed) > 0 {
+		os.Exit(1)
+	}
+}
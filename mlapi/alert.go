@@ -1,7 +1,6 @@
 package mlapi
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -101,116 +100,50 @@ type Alert struct {
 
 // NewJobAlert creates an alert for a job.
 func (c *Client) NewJobAlert(ctx context.Context, jobID string, alert Alert) (Alert, error) {
-	data, err := json.Marshal(alert)
-	if err != nil {
-		return Alert{}, err
-	}
-
-	result := responseWrapper[Alert]{}
-	err = c.request(ctx, "POST", fmt.Sprintf("/manage/api/v1/jobs/%s/alerts", jobID), nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Alert{}, err
-	}
-	return result.Data, nil
+	return c.JobAlertResource(jobID).Create(ctx, alert)
 }
 
 // JobAlerts fetches all alerts for a given Job.
 func (c *Client) JobAlerts(ctx context.Context, jobID string) ([]Alert, error) {
-	result := responseWrapper[[]Alert]{}
-	err := c.request(ctx, "GET", fmt.Sprintf("/manage/api/v1/jobs/%s/alerts", jobID), nil, nil, &result)
-	if err != nil {
-		return nil, err
-	}
-	return result.Data, nil
+	return c.JobAlertResource(jobID).List(ctx)
 }
 
 // JobAlert fetches an existing alert for the given machine learning job.
 func (c *Client) JobAlert(ctx context.Context, jobID, alertID string) (Alert, error) {
-	result := responseWrapper[Alert]{}
-	err := c.request(ctx, "GET", fmt.Sprintf("/manage/api/v1/jobs/%s/alerts/%s", jobID, alertID), nil, nil, &result)
-	if err != nil {
-		return Alert{}, err
-	}
-	return result.Data, nil
+	return c.JobAlertResource(jobID).Get(ctx, alertID)
 }
 
 // UpdateJobAlert updates the alert for a machine learning job.
 func (c *Client) UpdateJobAlert(ctx context.Context, jobID string, alert Alert) (Alert, error) {
-	alertID := alert.ID
-	// Clear the ID before sending otherwise validation fails.
-	alert.ID = ""
-	data, err := json.Marshal(alert)
-	if err != nil {
-		return Alert{}, err
-	}
-
-	result := responseWrapper[Alert]{}
-	err = c.request(ctx, "POST", fmt.Sprintf("/manage/api/v1/jobs/%s/alerts/%s", jobID, alertID), nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Alert{}, err
-	}
-	return result.Data, nil
+	return c.JobAlertResource(jobID).Update(ctx, alert)
 }
 
 // DeleteJobAlert deletes an alert on a job.
 func (c *Client) DeleteJobAlert(ctx context.Context, jobID, alertID string) error {
-	return c.request(ctx, "DELETE", fmt.Sprintf("/manage/api/v1/jobs/%s/alerts/%s", jobID, alertID), nil, nil, nil)
+	return c.JobAlertResource(jobID).Delete(ctx, alertID)
 }
 
 // NewOutlierAlert creates an alert for an outlier detector.
 func (c *Client) NewOutlierAlert(ctx context.Context, outlierID string, alert Alert) (Alert, error) {
-	data, err := json.Marshal(alert)
-	if err != nil {
-		return Alert{}, err
-	}
-
-	result := responseWrapper[Alert]{}
-	err = c.request(ctx, "POST", fmt.Sprintf("/manage/api/v1/outliers/%s/alerts", outlierID), nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Alert{}, err
-	}
-	return result.Data, nil
+	return c.OutlierAlertResource(outlierID).Create(ctx, alert)
 }
 
 // OutlierAlerts fetches all alerts for a given Job.
 func (c *Client) OutlierAlerts(ctx context.Context, outlierID string) ([]Alert, error) {
-	result := responseWrapper[[]Alert]{}
-	err := c.request(ctx, "GET", fmt.Sprintf("/manage/api/v1/outliers/%s/alerts", outlierID), nil, nil, &result)
-	if err != nil {
-		return nil, err
-	}
-	return result.Data, nil
+	return c.OutlierAlertResource(outlierID).List(ctx)
 }
 
 // JobAlert fetches an existing alert for the given outlier detector.
 func (c *Client) OutlierAlert(ctx context.Context, outlierID, alertID string) (Alert, error) {
-	result := responseWrapper[Alert]{}
-	err := c.request(ctx, "GET", fmt.Sprintf("/manage/api/v1/outliers/%s/alerts/%s", outlierID, alertID), nil, nil, &result)
-	if err != nil {
-		return Alert{}, err
-	}
-	return result.Data, nil
+	return c.OutlierAlertResource(outlierID).Get(ctx, alertID)
 }
 
 // UpdateJobAlert updates the alert for an outlier detector.
 func (c *Client) UpdateOutlierAlert(ctx context.Context, outlierID string, alert Alert) (Alert, error) {
-	alertID := alert.ID
-	// Clear the ID before sending otherwise validation fails.
-	alert.ID = ""
-	data, err := json.Marshal(alert)
-	if err != nil {
-		return Alert{}, err
-	}
-
-	result := responseWrapper[Alert]{}
-	err = c.request(ctx, "POST", fmt.Sprintf("/manage/api/v1/outliers/%s/alerts/%s", outlierID, alertID), nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Alert{}, err
-	}
-	return result.Data, nil
+	return c.OutlierAlertResource(outlierID).Update(ctx, alert)
 }
 
 // DeleteOutlierAlert deletes an alert on an outlier detector.
 func (c *Client) DeleteOutlierAlert(ctx context.Context, outlierID, alertID string) error {
-	return c.request(ctx, "DELETE", fmt.Sprintf("/manage/api/v1/outliers/%s/alerts/%s", outlierID, alertID), nil, nil, nil)
+	return c.OutlierAlertResource(outlierID).Delete(ctx, alertID)
 }
@@ -0,0 +1,268 @@
+// Package analysis scores forecasts against actual values the way forecast
+// alerts do, so that anomalies can be found and explained on the client with
+// the same rules the server alerts with.
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/machine-learning-go-client/mlapi"
+)
+
+// IsAnomalous reports whether actual is outside the prediction bounds in the
+// direction of condition: above upper for AnomalyConditionHigh, below lower
+// for AnomalyConditionLow, and either for AnomalyConditionAny or an empty
+// condition. Values on a bound are not anomalous, and neither are points
+// missing the actual value or the bound they are compared with.
+func IsAnomalous(condition mlapi.AnomalyCondition, actual, lower, upper float64) bool {
+	if math.IsNaN(actual) {
+		return false
+	}
+	high := !math.IsNaN(upper) && actual > upper
+	low := !math.IsNaN(lower) && actual < lower
+	switch condition {
+	case mlapi.AnomalyConditionHigh:
+		return high
+	case mlapi.AnomalyConditionLow:
+		return low
+	default:
+		return high || low
+	}
+}
+
+// Deviation is how far actual is from predicted, in units of the half width
+// of the prediction interval on that side of the prediction. Its magnitude is
+// above 1 when actual is outside the bounds, and its sign is the direction of
+// the deviation. It is NaN when a value is missing or the interval has no
+// width on that side.
+func Deviation(actual, predicted, lower, upper float64) float64 {
+	diff := actual - predicted
+	width := upper - predicted
+	if diff < 0 {
+		width = predicted - lower
+	}
+	if math.IsNaN(diff) || math.IsNaN(width) || width <= 0 {
+		return math.NaN()
+	}
+	return diff / width
+}
+
+// Threshold is a comparison of the anomaly ratio, such as `>0.7`, as in
+// Alert.Threshold.
+type Threshold struct {
+	Op    string
+	Value float64
+}
+
+// thresholdOps are the supported comparisons, longest first so that `>=` is
+// not parsed as `>`.
+var thresholdOps = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// ParseThreshold parses an alert threshold. An empty threshold alerts on any
+// anomalous point, as `>0`.
+func ParseThreshold(s string) (Threshold, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Threshold{Op: ">", Value: 0}, nil
+	}
+	for _, op := range thresholdOps {
+		if !strings.HasPrefix(s, op) {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(s[len(op):]), 64)
+		if err != nil {
+			return Threshold{}, fmt.Errorf("invalid threshold %q: %w", s, err)
+		}
+		return Threshold{Op: op, Value: value}, nil
+	}
+	return Threshold{}, fmt.Errorf("invalid threshold %q: must start with one of %s", s, strings.Join(thresholdOps, ", "))
+}
+
+// Met reports whether ratio meets the threshold. A NaN ratio, from a window
+// without data, never does.
+func (t Threshold) Met(ratio float64) bool {
+	if math.IsNaN(ratio) {
+		return false
+	}
+	switch t.Op {
+	case ">":
+		return ratio > t.Value
+	case ">=":
+		return ratio >= t.Value
+	case "<":
+		return ratio < t.Value
+	case "<=":
+		return ratio <= t.Value
+	case "==":
+		return ratio == t.Value
+	case "!=":
+		return ratio != t.Value
+	default:
+		return false
+	}
+}
+
+// String returns the threshold in the form Alert.Threshold takes.
+func (t Threshold) String() string {
+	return t.Op + strconv.FormatFloat(t.Value, 'g', -1, 64)
+}
+
+// Point is the analysis of one point of a forecast.
+type Point struct {
+	Time      time.Time
+	Actual    float64
+	Predicted float64
+	Lower     float64
+	Upper     float64
+	// Anomalous is whether the point is anomalous under the alert's anomaly
+	// condition.
+	Anomalous bool
+	// Deviation is the deviation score of the point; see Deviation.
+	Deviation float64
+	// Ratio is the fraction of anomalous points among the points with data in
+	// the alert window ending at the point. It is NaN if there are none.
+	Ratio float64
+	// Active is whether the ratio meets the alert threshold.
+	Active bool
+	// Firing is whether the alert has been active for at least its For
+	// duration at the point.
+	Firing bool
+}
+
+// Flags returns whether each point of the forecast is anomalous under
+// condition, aligned with f.Timestamps.
+func Flags(f mlapi.Forecast, condition mlapi.AnomalyCondition) []bool {
+	flags := make([]bool, len(f.Timestamps))
+	for i := range flags {
+		flags[i] = IsAnomalous(condition, value(f.Actual, i), value(f.Lower, i), value(f.Upper, i))
+	}
+	return flags
+}
+
+// Deviations returns the deviation score of each point of the forecast,
+// aligned with f.Timestamps.
+func Deviations(f mlapi.Forecast) []float64 {
+	scores := make([]float64, len(f.Timestamps))
+	for i := range scores {
+		scores[i] = Deviation(value(f.Actual, i), value(f.Predicted, i), value(f.Lower, i), value(f.Upper, i))
+	}
+	return scores
+}
+
+// Ratios returns, for each point of the forecast, the fraction of anomalous
+// points under condition in the window ending at the point, aligned with
+// f.Timestamps. The window includes its end but not its start; a zero window
+// holds just the point itself. Points missing the actual value or the bounds
+// are left out, and the ratio is NaN if no point in the window has data.
+// Timestamps must be in ascending order.
+func Ratios(f mlapi.Forecast, condition mlapi.AnomalyCondition, window time.Duration) []float64 {
+	flags := Flags(f, condition)
+	hasData := make([]bool, len(f.Timestamps))
+	for i := range hasData {
+		hasData[i] = !math.IsNaN(value(f.Actual, i)) && !math.IsNaN(value(f.Lower, i)) && !math.IsNaN(value(f.Upper, i))
+	}
+
+	ratios := make([]float64, len(f.Timestamps))
+	start, points, anomalous := 0, 0, 0
+	for i, t := range f.Timestamps {
+		if hasData[i] {
+			points++
+			if flags[i] {
+				anomalous++
+			}
+		}
+		for ; start < i && !f.Timestamps[start].After(t.Add(-window)); start++ {
+			if hasData[start] {
+				points--
+				if flags[start] {
+					anomalous--
+				}
+			}
+		}
+		if points == 0 {
+			ratios[i] = math.NaN()
+			continue
+		}
+		ratios[i] = float64(anomalous) / float64(points)
+	}
+	return ratios
+}
+
+// Evaluate evaluates alert at each point of the forecast, as the server
+// would if it evaluated the alert at the point's time. The anomaly ratio over
+// the alert's Window is compared with its Threshold, and the alert fires once
+// the threshold has been met continuously for its For duration. Timestamps
+// must be in ascending order.
+//
+// Alerts with a CustomQuery are evaluated as if they had none.
+func Evaluate(f mlapi.Forecast, alert mlapi.Alert) ([]Point, error) {
+	threshold, err := ParseThreshold(alert.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	flags := Flags(f, alert.AnomalyCondition)
+	ratios := Ratios(f, alert.AnomalyCondition, time.Duration(alert.Window))
+
+	points := make([]Point, len(f.Timestamps))
+	var activeSince time.Time
+	for i, t := range f.Timestamps {
+		p := Point{
+			Time:      t,
+			Actual:    value(f.Actual, i),
+			Predicted: value(f.Predicted, i),
+			Lower:     value(f.Lower, i),
+			Upper:     value(f.Upper, i),
+			Anomalous: flags[i],
+			Ratio:     ratios[i],
+			Active:    threshold.Met(ratios[i]),
+		}
+		p.Deviation = Deviation(p.Actual, p.Predicted, p.Lower, p.Upper)
+		if p.Active {
+			if i == 0 || !points[i-1].Active {
+				activeSince = t
+			}
+			p.Firing = t.Sub(activeSince) >= time.Duration(alert.For)
+		}
+		points[i] = p
+	}
+	return points, nil
+}
+
+// Accuracy computes the accuracy of the forecast against its actual values.
+func Accuracy(f mlapi.Forecast) mlapi.ForecastMetrics {
+	return mlapi.ComputeMetrics(f.Actual, f.Predicted, f.Lower, f.Upper)
+}
+
+// WithActuals returns a copy of f with its actual values taken from values,
+// matched by time. Points without an actual value are NaN. It is useful when
+// the actual values are queried separately from the forecast.
+func WithActuals(f mlapi.Forecast, timestamps []time.Time, values []float64) mlapi.Forecast {
+	byTime := make(map[int64]float64, len(timestamps))
+	for i, t := range timestamps {
+		if i < len(values) {
+			byTime[t.UnixNano()] = values[i]
+		}
+	}
+	actual := make([]float64, len(f.Timestamps))
+	for i, t := range f.Timestamps {
+		v, ok := byTime[t.UnixNano()]
+		if !ok {
+			v = math.NaN()
+		}
+		actual[i] = v
+	}
+	f.Actual = actual
+	return f
+}
+
+// value returns values[i], or NaN if values is too short.
+func value(values []float64, i int) float64 {
+	if i >= len(values) {
+		return math.NaN()
+	}
+	return values[i]
+}
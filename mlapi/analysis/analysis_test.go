@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/machine-learning-go-client/mlapi"
+)
+
+func TestIsAnomalous(t *testing.T) {
+	nan := math.NaN()
+	for _, tc := range []struct {
+		condition mlapi.AnomalyCondition
+		actual    float64
+		want      bool
+	}{
+		{mlapi.AnomalyConditionHigh, 11, true},
+		{mlapi.AnomalyConditionHigh, 10, false},
+		{mlapi.AnomalyConditionHigh, -1, false},
+		{mlapi.AnomalyConditionLow, -1, true},
+		{mlapi.AnomalyConditionLow, 0, false},
+		{mlapi.AnomalyConditionLow, 11, false},
+		{mlapi.AnomalyConditionAny, 11, true},
+		{mlapi.AnomalyConditionAny, -1, true},
+		{mlapi.AnomalyConditionAny, 5, false},
+		{"", -1, true},
+		{mlapi.AnomalyConditionAny, nan, false},
+	} {
+		assert.Equal(t, tc.want, IsAnomalous(tc.condition, tc.actual, 0, 10), "%s %v", tc.condition, tc.actual)
+	}
+	assert.True(t, IsAnomalous(mlapi.AnomalyConditionAny, 11, nan, 10))
+	assert.False(t, IsAnomalous(mlapi.AnomalyConditionHigh, 11, 0, nan))
+}
+
+func TestDeviation(t *testing.T) {
+	assert.InDelta(t, 0.5, Deviation(12, 10, 6, 14), 1e-9)
+	assert.InDelta(t, -2.0, Deviation(2, 10, 6, 14), 1e-9)
+	assert.InDelta(t, 1.5, Deviation(13, 10, 9, 12), 1e-9)
+	assert.Zero(t, Deviation(10, 10, 6, 14))
+	assert.True(t, math.IsNaN(Deviation(12, 10, 6, 10)))
+	assert.True(t, math.IsNaN(Deviation(math.NaN(), 10, 6, 14)))
+}
+
+func TestParseThreshold(t *testing.T) {
+	th, err := ParseThreshold(">0.7")
+	require.NoError(t, err)
+	assert.Equal(t, Threshold{Op: ">", Value: 0.7}, th)
+	assert.True(t, th.Met(0.8))
+	assert.False(t, th.Met(0.7))
+	assert.Equal(t, ">0.7", th.String())
+
+	th, err = ParseThreshold(" >= 0.5 ")
+	require.NoError(t, err)
+	assert.Equal(t, Threshold{Op: ">=", Value: 0.5}, th)
+	assert.True(t, th.Met(0.5))
+	assert.False(t, th.Met(math.NaN()))
+
+	th, err = ParseThreshold("")
+	require.NoError(t, err)
+	assert.True(t, th.Met(0.1))
+	assert.False(t, th.Met(0))
+
+	_, err = ParseThreshold("0.7")
+	assert.Error(t, err)
+	_, err = ParseThreshold(">high")
+	assert.Error(t, err)
+}
+
+func testForecast(actual ...float64) mlapi.Forecast {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := mlapi.Forecast{}
+	for i, a := range actual {
+		f.Timestamps = append(f.Timestamps, start.Add(time.Duration(i)*time.Minute))
+		f.Actual = append(f.Actual, a)
+		f.Predicted = append(f.Predicted, 5)
+		f.Lower = append(f.Lower, 0)
+		f.Upper = append(f.Upper, 10)
+	}
+	return f
+}
+
+func TestFlagsAndRatios(t *testing.T) {
+	f := testForecast(11, 5, -1, math.NaN(), 12, 5)
+
+	assert.Equal(t, []bool{true, false, false, false, true, false}, Flags(f, mlapi.AnomalyConditionHigh))
+	assert.Equal(t, []bool{true, false, true, false, true, false}, Flags(f, mlapi.AnomalyConditionAny))
+
+	ratios := Ratios(f, mlapi.AnomalyConditionAny, 0)
+	assert.Equal(t, []float64{1, 0, 1}, ratios[:3])
+	assert.True(t, math.IsNaN(ratios[3]))
+	assert.Equal(t, []float64{1, 0}, ratios[4:])
+
+	// A 2 minute window holds the point and the one before it; missing
+	// points are left out.
+	ratios = Ratios(f, mlapi.AnomalyConditionAny, 2*time.Minute)
+	assert.Equal(t, []float64{1, 0.5, 0.5, 1, 1, 0.5}, ratios)
+}
+
+func TestEvaluate(t *testing.T) {
+	f := testForecast(5, 11, 12, 13, 5, 11)
+	points, err := Evaluate(f, mlapi.Alert{
+		AnomalyCondition: mlapi.AnomalyConditionHigh,
+		Window:           model.Duration(2 * time.Minute),
+		Threshold:        ">0.7",
+		For:              model.Duration(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, points, 6)
+
+	var active, firing []bool
+	for _, p := range points {
+		active = append(active, p.Active)
+		firing = append(firing, p.Firing)
+	}
+	assert.Equal(t, []bool{false, false, true, true, false, false}, active)
+	assert.Equal(t, []bool{false, false, false, true, false, false}, firing)
+	assert.Equal(t, 11.0, points[1].Actual)
+	assert.True(t, points[1].Anomalous)
+	assert.InDelta(t, 1.2, points[1].Deviation, 1e-9)
+	assert.InDelta(t, 0.5, points[1].Ratio, 1e-9)
+
+	_, err = Evaluate(f, mlapi.Alert{Threshold: "70%"})
+	assert.Error(t, err)
+}
+
+func TestWithActuals(t *testing.T) {
+	f := testForecast(1, 2, 3)
+	f = WithActuals(f, []time.Time{f.Timestamps[2], f.Timestamps[0]}, []float64{30, 10})
+	assert.Equal(t, 10.0, f.Actual[0])
+	assert.True(t, math.IsNaN(f.Actual[1]))
+	assert.Equal(t, 30.0, f.Actual[2])
+
+	m := Accuracy(f)
+	assert.Equal(t, 2, m.Points)
+	assert.InDelta(t, 15.0, m.MAE, 1e-9)
+}
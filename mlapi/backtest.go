@@ -0,0 +1,326 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// BacktestWindow is a period of the past to forecast in a backtest. The
+// forecast from Cutoff to End is compared with the actual values.
+type BacktestWindow struct {
+	Cutoff time.Time
+	End    time.Time
+}
+
+// BacktestWindows returns count consecutive windows of length horizon, the
+// last of which ends at end. The windows need not be aligned to the job's
+// interval; see Backtest for how points are matched.
+func BacktestWindows(end time.Time, horizon time.Duration, count int) []BacktestWindow {
+	windows := make([]BacktestWindow, count)
+	for i := range windows {
+		windowEnd := end.Add(-time.Duration(count-1-i) * horizon)
+		windows[i] = BacktestWindow{Cutoff: windowEnd.Add(-horizon), End: windowEnd}
+	}
+	return windows
+}
+
+// BacktestOptions configure Backtest.
+type BacktestOptions struct {
+	// Concurrency is the number of windows backtested in parallel. Defaults
+	// to DefaultBulkConcurrency.
+	Concurrency int
+}
+
+// SeriesBacktest is the accuracy of the forecast of one series in a window.
+type SeriesBacktest struct {
+	Labels  data.Labels
+	Metrics ForecastMetrics
+}
+
+// WindowBacktest is the outcome of backtesting one window.
+type WindowBacktest struct {
+	Window BacktestWindow
+	// Series are the metrics of each forecast series.
+	Series []SeriesBacktest
+	// Metrics pool the points of every series.
+	Metrics ForecastMetrics
+	// Err is the error backtesting the window, if any.
+	Err error
+
+	acc metricsAccumulator
+}
+
+// BacktestReport is the outcome of a backtest.
+type BacktestReport struct {
+	Windows []WindowBacktest
+	// Summary pools the points of every window that succeeded.
+	Summary ForecastMetrics
+}
+
+// Backtest measures how well a job would have forecast the past. For each
+// window it runs ForecastJob from the window's cutoff to its end, queries the
+// actual values for the same period through the job's datasource, and
+// computes the accuracy of each forecast series against the actual series
+// with the same labels. Each forecast point is compared with the actual value
+// at the same time or, failing that, the nearest one within half the job's
+// interval, as the forecast and the query may not start at the same offset.
+//
+// The job is only trained on data before the cutoff: its query is filtered to
+// return no points at or after it, whatever period the forecast API trains on.
+// Only Prometheus queries are supported, as other datasources give no way to
+// bound the query.
+//
+// Backtest carries on past windows that fail, recording their error in the
+// report, and returns an error joining them. See BacktestBaseline to compare
+// the job with a simple baseline over the same windows.
+func (c *Client) Backtest(ctx context.Context, job Job, windows []BacktestWindow, opts BacktestOptions) (BacktestReport, error) {
+	if err := checkBacktestJob(job); err != nil {
+		return BacktestReport{}, err
+	}
+	return runBacktest(ctx, windows, opts, time.Duration(job.Interval)*time.Second, c.backtestForecast(job), c.backtestActuals(job))
+}
+
+// checkBacktestJob returns an error if the training data of job cannot be
+// limited to before a cutoff.
+func checkBacktestJob(job Job) error {
+	if job.DatasourceType != DatasourceTypePrometheus {
+		return fmt.Errorf("backtests are only supported for %s queries, not %q", DatasourceTypePrometheus, job.DatasourceType)
+	}
+	if expr, _ := job.QueryParams["expr"].(string); expr == "" {
+		return errors.New("job query has no expr")
+	}
+	return nil
+}
+
+// backtestForecast returns a function forecasting a backtest window with
+// ForecastJob, trained only on data before the window's cutoff. The job must
+// pass checkBacktestJob.
+func (c *Client) backtestForecast(job Job) func(context.Context, BacktestWindow) ([]Forecast, error) {
+	expr, _ := job.QueryParams["expr"].(string)
+	return func(ctx context.Context, w BacktestWindow) ([]Forecast, error) {
+		resp, err := c.ForecastJob(ctx, ForecastRequest{
+			Job:            withExpr(job, before(expr, w.Cutoff)),
+			ForecastParams: ForecastParams{Start: w.Cutoff, End: w.End, Interval: job.Interval},
+		})
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := DecodeForecasts(resp)
+		if err != nil {
+			return nil, err
+		}
+		var forecasts []Forecast
+		for _, fs := range decoded {
+			forecasts = append(forecasts, fs...)
+		}
+		return forecasts, nil
+	}
+}
+
+// backtestActuals returns a function querying the actual values of a backtest
+// window through the job's datasource.
+func (c *Client) backtestActuals(job Job) func(context.Context, BacktestWindow) (data.Frames, error) {
+	return func(ctx context.Context, w BacktestWindow) (data.Frames, error) {
+		return c.queryDatasource(ctx, datasourceQuery{
+			datasourceUID:  job.DatasourceUID,
+			datasourceID:   job.DatasourceID,
+			datasourceType: job.DatasourceType,
+			model:          job.QueryParams,
+			from:           w.Cutoff,
+			to:             w.End,
+			interval:       time.Duration(job.Interval) * time.Second,
+		})
+	}
+}
+
+// runBacktest backtests each window with forecasts from forecast, compared
+// with actual values from the frames returned by actuals at most step/2 away.
+func runBacktest(
+	ctx context.Context,
+	windows []BacktestWindow,
+	opts BacktestOptions,
+	step time.Duration,
+	forecast func(context.Context, BacktestWindow) ([]Forecast, error),
+	actuals func(context.Context, BacktestWindow) (data.Frames, error),
+) (BacktestReport, error) {
+	results, _ := runBulk(ctx, "", "backtest", windows, BulkOptions{Concurrency: opts.Concurrency},
+		func(ctx context.Context, w BacktestWindow) (WindowBacktest, error) {
+			forecasts, err := forecast(ctx, w)
+			if err != nil {
+				return WindowBacktest{}, fmt.Errorf("forecasting: %w", err)
+			}
+			frames, err := actuals(ctx, w)
+			if err != nil {
+				return WindowBacktest{}, fmt.Errorf("querying actual values: %w", err)
+			}
+			actual, err := actualSeries(frames)
+			if err != nil {
+				return WindowBacktest{}, fmt.Errorf("decoding actual values: %w", err)
+			}
+			return scoreWindow(w, step, forecasts, actual), nil
+		}, nil, nil)
+
+	report := BacktestReport{Windows: make([]WindowBacktest, len(results))}
+	var (
+		summary metricsAccumulator
+		errs    []error
+	)
+	for i, res := range results {
+		wb := res.Item
+		wb.Window = windows[i]
+		if res.Err != nil {
+			wb.Err = res.Err
+			errs = append(errs, fmt.Errorf("window %s: %w", windows[i].Cutoff.UTC().Format(time.RFC3339), res.Err))
+		}
+		summary.merge(wb.acc)
+		report.Windows[i] = wb
+	}
+	report.Summary = summary.metrics()
+	return report, errors.Join(errs...)
+}
+
+// timeSeries is a series of actual values keyed by time.
+type timeSeries struct {
+	labels data.Labels
+	values map[time.Time]float64
+	// times are the keys of values, in order.
+	times []time.Time
+}
+
+// at returns the value at t or, if there is none, at the nearest time at most
+// step/2 away.
+func (s timeSeries) at(t time.Time, step time.Duration) (float64, bool) {
+	if v, ok := s.values[t]; ok {
+		return v, true
+	}
+	i := sort.Search(len(s.times), func(i int) bool { return !s.times[i].Before(t) })
+	nearest, best := time.Time{}, step/2+1
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(s.times) {
+			continue
+		}
+		if d := s.times[j].Sub(t).Abs(); d < best {
+			nearest, best = s.times[j], d
+		}
+	}
+	if nearest.IsZero() {
+		return 0, false
+	}
+	return s.values[nearest], true
+}
+
+// actualSeries returns the series in frames, one per numeric field, labeled
+// without their metric name. It returns an error if the fields of a frame
+// have different lengths.
+func actualSeries(frames data.Frames) ([]timeSeries, error) {
+	var all []timeSeries
+	for _, frame := range frames {
+		if _, err := frame.RowLen(); err != nil {
+			return nil, fmt.Errorf("frame %q: %w", frame.Name, err)
+		}
+		var times *data.Field
+		for _, field := range frame.Fields {
+			if field.Type().Time() {
+				times = field
+				break
+			}
+		}
+		if times == nil {
+			continue
+		}
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+			s := timeSeries{labels: withoutLabel(field.Labels, nameLabel), values: map[time.Time]float64{}}
+			for i := 0; i < field.Len(); i++ {
+				t, ok := timeAt(times, i)
+				if !ok {
+					continue
+				}
+				if v, err := field.NullableFloatAt(i); err == nil && v != nil {
+					s.values[t] = *v
+				}
+			}
+			for t := range s.values {
+				s.times = append(s.times, t)
+			}
+			sort.Slice(s.times, func(a, b int) bool { return s.times[a].Before(s.times[b]) })
+			all = append(all, s)
+		}
+	}
+	return all, nil
+}
+
+// scoreWindow compares each forecast with the actual series with the same
+// labels, matching points at most step/2 apart. A single forecast and actual
+// series are compared whatever their labels, as ephemeral forecasts are not
+// always labeled.
+func scoreWindow(w BacktestWindow, step time.Duration, forecasts []Forecast, actuals []timeSeries) WindowBacktest {
+	wb := WindowBacktest{Window: w}
+	for _, f := range forecasts {
+		var actual *timeSeries
+		for i := range actuals {
+			if actuals[i].labels.Equals(f.Labels) {
+				actual = &actuals[i]
+				break
+			}
+		}
+		if actual == nil && len(forecasts) == 1 && len(actuals) == 1 {
+			actual = &actuals[0]
+		}
+
+		var acc metricsAccumulator
+		if actual != nil {
+			for i, t := range f.Timestamps {
+				if t.Before(w.Cutoff) || t.After(w.End) {
+					continue
+				}
+				a, ok := actual.at(t, step)
+				if !ok {
+					a = math.NaN()
+				}
+				acc.add(a, at(f.Predicted, i), at(f.Lower, i), at(f.Upper, i))
+			}
+		}
+		wb.Series = append(wb.Series, SeriesBacktest{Labels: f.Labels, Metrics: acc.metrics()})
+		wb.acc.merge(acc)
+	}
+	wb.Metrics = wb.acc.metrics()
+	return wb
+}
+
+// WriteTable writes the metrics of each window and the summary as an aligned
+// text table.
+func (r BacktestReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+	row := func(name string, m ForecastMetrics) {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", name, m.Points,
+			format(m.MAE), format(m.MAPE), format(m.SMAPE), format(m.RMSE), format(m.Coverage))
+	}
+	fmt.Fprintln(tw, "WINDOW\tPOINTS\tMAE\tMAPE\tSMAPE\tRMSE\tCOVERAGE")
+	for _, wb := range r.Windows {
+		name := wb.Window.Cutoff.UTC().Format(time.RFC3339)
+		if wb.Err != nil {
+			msg := strings.ReplaceAll(strings.TrimSpace(wb.Err.Error()), "\n", "; ")
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\t-\terror: %s\n", name, msg)
+			continue
+		}
+		row(name, wb.Metrics)
+	}
+	row("summary", r.Summary)
+	return tw.Flush()
+}
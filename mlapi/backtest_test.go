@@ -0,0 +1,136 @@
+package mlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBacktestWindows(t *testing.T) {
+	end := time.Unix(3600*10, 0)
+	assert.Equal(t, []BacktestWindow{
+		{Cutoff: end.Add(-3 * time.Hour), End: end.Add(-2 * time.Hour)},
+		{Cutoff: end.Add(-2 * time.Hour), End: end.Add(-time.Hour)},
+		{Cutoff: end.Add(-time.Hour), End: end},
+	}, BacktestWindows(end, time.Hour, 3))
+}
+
+func TestBacktest(t *testing.T) {
+	// The series is 10 everywhere, and the job forecasts 12 within 11 to 13,
+	// except in the window at 2h where the forecast fails.
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/predict/api/v1/forecast":
+			spec := ForecastRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&spec))
+			params := spec.ForecastParams
+			// The job is trained only on data before the cutoff.
+			assert.Equal(t, "(cpu\n) and on() (vector(time()) < "+strconv.FormatInt(params.Start.Unix(), 10)+")", spec.Job.QueryParams["expr"])
+			if params.Start.Equal(time.Unix(7200, 0)) {
+				http.Error(w, "not enough data", http.StatusBadRequest)
+				return
+			}
+			times := []time.Time{params.Start, params.End}
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+				data.NewFrame("",
+					data.NewField("time", nil, times),
+					data.NewField("yhat", nil, []float64{12, 12}),
+					data.NewField("yhat_lower", nil, []float64{11, 11}),
+					data.NewField("yhat_upper", nil, []float64{13, 13}),
+				),
+			}}
+			_ = json.NewEncoder(w).Encode(responseWrapper[*backend.QueryDataResponse]{Data: resp})
+		case dsQueryPath:
+			body := struct {
+				From string `json:"from"`
+				To   string `json:"to"`
+			}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			from, _ := strconv.ParseInt(body.From, 10, 64)
+			to, _ := strconv.ParseInt(body.To, 10, 64)
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+				data.NewFrame("",
+					data.NewField("Time", nil, []time.Time{time.UnixMilli(from), time.UnixMilli(to)}),
+					data.NewField("Value", data.Labels{nameLabel: "cpu"}, []float64{10, 9}),
+				),
+			}}
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	job := prometheusJob(t, "cpu", "cpu", "prom", "cpu")
+	windows := BacktestWindows(time.Unix(4*3600, 0), time.Hour, 3)
+	report, err := c.Backtest(context.Background(), job, windows, BacktestOptions{Concurrency: 2})
+	require.EqualError(t, err, "window 1970-01-01T02:00:00Z: forecasting: status: 400, body: not enough data\n")
+
+	require.Len(t, report.Windows, 3)
+	assert.Error(t, report.Windows[1].Err)
+	w := report.Windows[0]
+	require.NoError(t, w.Err)
+	require.Len(t, w.Series, 1)
+	assert.Equal(t, 2, w.Metrics.Points)
+	assert.InDelta(t, 2.5, w.Metrics.MAE, 1e-9)
+	assert.InDelta(t, 0.0, w.Metrics.Coverage, 1e-9)
+
+	assert.Equal(t, 4, report.Summary.Points)
+	assert.InDelta(t, 2.5, report.Summary.MAE, 1e-9)
+
+	// The query itself is left alone for the actual values.
+	assert.Equal(t, "cpu", job.QueryParams["expr"])
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteTable(&buf))
+	assert.Equal(t, `WINDOW                POINTS  MAE    MAPE    SMAPE   RMSE   COVERAGE
+1970-01-01T01:00:00Z  2       2.500  26.667  23.377  2.550  0.000
+1970-01-01T02:00:00Z  -       -      -       -       -      -  error: forecasting: status: 400, body: not enough data
+1970-01-01T03:00:00Z  2       2.500  26.667  23.377  2.550  0.000
+summary               4       2.500  26.667  23.377  2.550  0.000
+`, buf.String())
+}
+
+func TestBacktestUnsupportedDatasource(t *testing.T) {
+	c, err := New("http://localhost", Config{})
+	require.NoError(t, err)
+
+	job := Job{Name: "cpu", DatasourceType: "loki", QueryParams: map[string]interface{}{"expr": "rate({app=\"a\"}[5m])"}}
+	_, err = c.Backtest(context.Background(), job, BacktestWindows(time.Unix(3600, 0), time.Hour, 1), BacktestOptions{})
+	require.EqualError(t, err, `backtests are only supported for prometheus queries, not "loki"`)
+
+	job = Job{Name: "cpu", DatasourceType: DatasourceTypePrometheus}
+	_, err = c.Backtest(context.Background(), job, BacktestWindows(time.Unix(3600, 0), time.Hour, 1), BacktestOptions{})
+	require.EqualError(t, err, "job query has no expr")
+}
+
+func TestScoreWindowUnaligned(t *testing.T) {
+	// The actual values are at whole minutes, but the forecast starts 20s
+	// in, as it does for windows not aligned to the interval.
+	actuals, err := actualSeries(data.Frames{seriesFrame(nil, 1, 2, 3, 4)})
+	require.NoError(t, err)
+	at := func(seconds int64) time.Time { return time.Unix(seconds, 0).UTC() }
+	forecast := Forecast{
+		Timestamps: []time.Time{at(20), at(80), at(140), at(300)},
+		Predicted:  []float64{2, 3, 4, 5},
+	}
+	wb := scoreWindow(BacktestWindow{Cutoff: at(0), End: at(300)}, time.Minute, []Forecast{forecast}, actuals)
+	// The last point has no actual value within 30s.
+	assert.Equal(t, 3, wb.Metrics.Points)
+	assert.InDelta(t, 1, wb.Metrics.MAE, 1e-9)
+}
@@ -0,0 +1,379 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// baselineZ is the z-score of the prediction intervals of baselines, for an
+// approximate 95% interval.
+const baselineZ = 1.96
+
+// Series is an observed series, the input of a Baseline. Missing values are
+// NaN.
+type Series struct {
+	Labels     data.Labels
+	Timestamps []time.Time
+	Values     []float64
+}
+
+// Baseline is a simple forecasting model run on the client, to compare the
+// forecasts of a job against. Baselines forecast from Start to End at Interval
+// like ForecastJob, from a history of the series before Start.
+//
+// The history is resampled at Interval, ending one interval before Start, with
+// gaps filled by the last observed value. The prediction interval is an
+// approximate 95% interval from the model's one step errors on the history.
+type Baseline interface {
+	Forecast(history Series, params ForecastParams) (Forecast, error)
+}
+
+// SeasonalNaive forecasts each point as the value one season earlier.
+type SeasonalNaive struct {
+	// Period is the length of a season. Zero forecasts the last value.
+	Period time.Duration
+}
+
+// Forecast implements Baseline.
+func (m SeasonalNaive) Forecast(history Series, params ForecastParams) (Forecast, error) {
+	return forecastBaseline(history, params, func(y []float64, step time.Duration, h int) ([]float64, []float64, error) {
+		season, err := seasonLength(m.Period, step)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(y) < season {
+			return nil, nil, notEnoughData(season, len(y))
+		}
+		predicted := make([]float64, h)
+		for k := range predicted {
+			predicted[k] = y[len(y)-season+k%season]
+		}
+		residuals := make([]float64, 0, len(y)-season)
+		for t := season; t < len(y); t++ {
+			residuals = append(residuals, y[t]-y[t-season])
+		}
+		return predicted, residuals, nil
+	})
+}
+
+// MovingAverage forecasts every point as the mean of the last values.
+type MovingAverage struct {
+	// Window is the period averaged. Zero averages the whole history.
+	Window time.Duration
+}
+
+// Forecast implements Baseline.
+func (m MovingAverage) Forecast(history Series, params ForecastParams) (Forecast, error) {
+	return forecastBaseline(history, params, func(y []float64, step time.Duration, h int) ([]float64, []float64, error) {
+		window := len(y)
+		if m.Window > 0 {
+			window = int(m.Window / step)
+		}
+		if window < 1 {
+			return nil, nil, fmt.Errorf("window %s is shorter than the interval %s", m.Window, step)
+		}
+		if len(y) < window {
+			return nil, nil, notEnoughData(window, len(y))
+		}
+		// sum is the sum of y[max(0, t-window):t], kept as the window slides
+		// rather than recomputed at every t.
+		sum := 0.0
+		residuals := make([]float64, 0, len(y))
+		for t := 1; t <= len(y); t++ {
+			sum += y[t-1]
+			if t > window {
+				sum -= y[t-1-window]
+			}
+			if t < len(y) {
+				residuals = append(residuals, y[t]-sum/float64(min(t, window)))
+			}
+		}
+		predicted := make([]float64, h)
+		for k := range predicted {
+			predicted[k] = sum / float64(window)
+		}
+		return predicted, residuals, nil
+	})
+}
+
+// HoltWinters is triple exponential smoothing, forecasting a level, a trend and
+// a seasonal component. It needs at least two seasons of history.
+type HoltWinters struct {
+	// Period is the length of a season.
+	Period time.Duration
+	// Alpha, Beta and Gamma smooth the level, trend and seasonal component,
+	// from 0 to 1. If all are zero, they default to 0.5, 0.1 and 0.1.
+	Alpha, Beta, Gamma float64
+	// Multiplicative makes the seasonal component scale with the level, rather
+	// than add to it. The series must then be positive.
+	Multiplicative bool
+}
+
+// Forecast implements Baseline.
+func (m HoltWinters) Forecast(history Series, params ForecastParams) (Forecast, error) {
+	alpha, beta, gamma := m.Alpha, m.Beta, m.Gamma
+	if alpha == 0 && beta == 0 && gamma == 0 {
+		alpha, beta, gamma = 0.5, 0.1, 0.1
+	}
+	for _, p := range []float64{alpha, beta, gamma} {
+		if p < 0 || p > 1 {
+			return Forecast{}, fmt.Errorf("smoothing parameters must be between 0 and 1, got %v", p)
+		}
+	}
+	return forecastBaseline(history, params, func(y []float64, step time.Duration, h int) ([]float64, []float64, error) {
+		season, err := seasonLength(m.Period, step)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(y) < 2*season {
+			return nil, nil, notEnoughData(2*season, len(y))
+		}
+		if m.Multiplicative {
+			for _, v := range y {
+				if v <= 0 {
+					return nil, nil, errors.New("multiplicative seasonality needs a positive series")
+				}
+			}
+		}
+		// combine applies the seasonal component to a level, and remove
+		// takes it out of a value.
+		combine := func(level, seasonal float64) float64 { return level + seasonal }
+		remove := func(value, seasonal float64) float64 { return value - seasonal }
+		if m.Multiplicative {
+			combine = func(level, seasonal float64) float64 { return level * seasonal }
+			remove = func(value, seasonal float64) float64 { return value / seasonal }
+		}
+
+		// The first two seasons initialize the level, trend and seasonal
+		// component.
+		first, second := 0.0, 0.0
+		for i := 0; i < season; i++ {
+			first += y[i] / float64(season)
+			second += y[season+i] / float64(season)
+		}
+		// The mean of the first season is the level at its middle.
+		trend := (second - first) / float64(season)
+		middle := float64(season-1) / 2
+		level := first + middle*trend
+		seasonal := make([]float64, len(y))
+		for i := 0; i < season; i++ {
+			seasonal[i] = remove(y[i], first+(float64(i)-middle)*trend)
+		}
+
+		residuals := make([]float64, 0, len(y)-season)
+		for t := season; t < len(y); t++ {
+			residuals = append(residuals, y[t]-combine(level+trend, seasonal[t-season]))
+			prevLevel := level
+			level = alpha*remove(y[t], seasonal[t-season]) + (1-alpha)*(level+trend)
+			trend = beta*(level-prevLevel) + (1-beta)*trend
+			seasonal[t] = gamma*remove(y[t], level) + (1-gamma)*seasonal[t-season]
+		}
+
+		predicted := make([]float64, h)
+		for k := range predicted {
+			predicted[k] = combine(level+float64(k+1)*trend, seasonal[len(y)-season+k%season])
+		}
+		return predicted, residuals, nil
+	})
+}
+
+// baselineFit fits a model to the regular series y, sampled every step, and
+// returns h predicted values following it and the model's one step errors on
+// y.
+type baselineFit func(y []float64, step time.Duration, h int) (predicted, residuals []float64, err error)
+
+// forecastBaseline resamples history and turns the output of fit into a
+// Forecast.
+func forecastBaseline(history Series, params ForecastParams, fit baselineFit) (Forecast, error) {
+	if params.Interval == 0 {
+		return Forecast{}, errors.New("interval must be positive")
+	}
+	if params.End.Before(params.Start) {
+		return Forecast{}, errors.New("end must not be before start")
+	}
+	step := time.Duration(params.Interval) * time.Second
+	y := resample(history, params.Start, step)
+
+	f := Forecast{Labels: withoutLabel(history.Labels, nameLabel)}
+	for t := params.Start.UTC(); !t.After(params.End); t = t.Add(step) {
+		f.Timestamps = append(f.Timestamps, t)
+	}
+	predicted, residuals, err := fit(y, step, len(f.Timestamps))
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	width := math.NaN()
+	if len(residuals) > 0 {
+		sum := 0.0
+		for _, r := range residuals {
+			sum += r * r
+		}
+		width = baselineZ * math.Sqrt(sum/float64(len(residuals)))
+	}
+	f.Predicted = predicted
+	f.Actual = make([]float64, len(predicted))
+	f.Lower = make([]float64, len(predicted))
+	f.Upper = make([]float64, len(predicted))
+	for i, p := range predicted {
+		f.Actual[i] = math.NaN()
+		f.Lower[i] = p - width
+		f.Upper[i] = p + width
+	}
+	return f, nil
+}
+
+// resample returns the values of history every step, ending one step before
+// start, each the last value observed at or before its time. It starts at the
+// first observed value.
+func resample(history Series, start time.Time, step time.Duration) []float64 {
+	type point struct {
+		t time.Time
+		v float64
+	}
+	var points []point
+	for i, t := range history.Timestamps {
+		if v := at(history.Values, i); !math.IsNaN(v) && t.Before(start) {
+			points = append(points, point{t, v})
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	sort.Slice(points, func(a, b int) bool { return points[a].t.Before(points[b].t) })
+
+	n := int(start.Sub(points[0].t) / step)
+	y := make([]float64, 0, n)
+	j := 0
+	for k := n; k >= 1; k-- {
+		t := start.Add(-time.Duration(k) * step)
+		for j+1 < len(points) && !points[j+1].t.After(t) {
+			j++
+		}
+		y = append(y, points[j].v)
+	}
+	return y
+}
+
+func seasonLength(period, step time.Duration) (int, error) {
+	if period == 0 {
+		return 1, nil
+	}
+	if period < step || period%step != 0 {
+		return 0, fmt.Errorf("period %s must be a multiple of the interval %s", period, step)
+	}
+	return int(period / step), nil
+}
+
+func notEnoughData(need, have int) error {
+	return fmt.Errorf("not enough data: need %d points, have %d", need, have)
+}
+
+// BacktestBaseline backtests a baseline on series held in memory, without any
+// requests. The baseline is given the job's TrainingWindow of each series
+// before the cutoff of each window, and no data after it, forecasts to the
+// window's end at the job's Interval, and is compared with the actual values
+// of the series in the window as in Backtest. The report can be compared side
+// by side with that of Backtest for the same job and windows.
+func BacktestBaseline(ctx context.Context, job Job, baseline Baseline, series []Series, windows []BacktestWindow, opts BacktestOptions) (BacktestReport, error) {
+	training := time.Duration(job.TrainingWindow) * time.Second
+	forecast := func(_ context.Context, w BacktestWindow) ([]Forecast, error) {
+		history := make([]Series, len(series))
+		for i, s := range series {
+			history[i] = s.between(w.Cutoff.Add(-training), w.Cutoff)
+		}
+		return forecastBaselineSeries(job, baseline, history, w)
+	}
+	actuals := func(_ context.Context, w BacktestWindow) (data.Frames, error) {
+		frames := make(data.Frames, len(series))
+		for i, s := range series {
+			frames[i] = s.between(w.Cutoff, w.End.Add(time.Nanosecond)).frame()
+		}
+		return frames, nil
+	}
+	return runBacktest(ctx, windows, opts, time.Duration(job.Interval)*time.Second, forecast, actuals)
+}
+
+// BacktestBaseline backtests a baseline like the package function of the same
+// name, on the job's series queried through its datasource. Only the data is
+// queried; the baseline runs on the client.
+func (c *Client) BacktestBaseline(ctx context.Context, job Job, baseline Baseline, windows []BacktestWindow, opts BacktestOptions) (BacktestReport, error) {
+	training := time.Duration(job.TrainingWindow) * time.Second
+	queryActuals := c.backtestActuals(job)
+	forecast := func(ctx context.Context, w BacktestWindow) ([]Forecast, error) {
+		frames, err := queryActuals(ctx, BacktestWindow{Cutoff: w.Cutoff.Add(-training), End: w.Cutoff})
+		if err != nil {
+			return nil, fmt.Errorf("querying history: %w", err)
+		}
+		history, err := seriesFromFrames(frames)
+		if err != nil {
+			return nil, fmt.Errorf("decoding history: %w", err)
+		}
+		return forecastBaselineSeries(job, baseline, history, w)
+	}
+	return runBacktest(ctx, windows, opts, time.Duration(job.Interval)*time.Second, forecast, queryActuals)
+}
+
+// forecastBaselineSeries forecasts a backtest window of each series with a
+// baseline.
+func forecastBaselineSeries(job Job, baseline Baseline, history []Series, w BacktestWindow) ([]Forecast, error) {
+	params := ForecastParams{Start: w.Cutoff, End: w.End, Interval: job.Interval}
+	forecasts := make([]Forecast, 0, len(history))
+	var errs []error
+	for _, s := range history {
+		f, err := baseline.Forecast(s, params)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("series {%s}: %w", s.Labels, err))
+			continue
+		}
+		forecasts = append(forecasts, f)
+	}
+	return forecasts, errors.Join(errs...)
+}
+
+// between returns the points of s from start, up to but not including end.
+func (s Series) between(start, end time.Time) Series {
+	out := Series{Labels: s.Labels}
+	for i, t := range s.Timestamps {
+		if !t.Before(start) && t.Before(end) {
+			out.Timestamps = append(out.Timestamps, t)
+			out.Values = append(out.Values, at(s.Values, i))
+		}
+	}
+	return out
+}
+
+func (s Series) frame() *data.Frame {
+	return data.NewFrame("",
+		data.NewField("time", nil, s.Timestamps),
+		data.NewField("value", s.Labels, s.Values),
+	)
+}
+
+// seriesFromFrames returns the series in frames, one per numeric field,
+// ordered by time.
+func seriesFromFrames(frames data.Frames) ([]Series, error) {
+	actual, err := actualSeries(frames)
+	if err != nil {
+		return nil, err
+	}
+	var all []Series
+	for _, ts := range actual {
+		s := Series{Labels: ts.labels}
+		for t := range ts.values {
+			s.Timestamps = append(s.Timestamps, t)
+		}
+		sort.Slice(s.Timestamps, func(a, b int) bool { return s.Timestamps[a].Before(s.Timestamps[b]) })
+		for _, t := range s.Timestamps {
+			s.Values = append(s.Values, ts.values[t])
+		}
+		all = append(all, s)
+	}
+	return all, nil
+}
@@ -0,0 +1,206 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// regularSeries returns a series with a value every minute from start.
+func regularSeries(start time.Time, values ...float64) Series {
+	s := Series{Labels: data.Labels{nameLabel: "cpu", "pod": "a"}, Values: values}
+	for i := range values {
+		s.Timestamps = append(s.Timestamps, start.Add(time.Duration(i)*time.Minute))
+	}
+	return s
+}
+
+func TestSeasonalNaive(t *testing.T) {
+	start := time.Unix(0, 0).UTC()
+	history := regularSeries(start, 1, 2, 3, 4, 1, 2, 3, 4)
+	params := ForecastParams{Start: start.Add(8 * time.Minute), End: start.Add(13 * time.Minute), Interval: 60}
+
+	f, err := SeasonalNaive{Period: 4 * time.Minute}.Forecast(history, params)
+	require.NoError(t, err)
+	assert.Equal(t, data.Labels{"pod": "a"}, f.Labels)
+	require.Len(t, f.Timestamps, 6)
+	assert.Equal(t, params.Start, f.Timestamps[0])
+	assert.Equal(t, []float64{1, 2, 3, 4, 1, 2}, f.Predicted)
+	// The history repeats exactly, so the interval has no width.
+	assert.Equal(t, f.Predicted, f.Lower)
+	assert.Equal(t, f.Predicted, f.Upper)
+	assert.True(t, math.IsNaN(f.Actual[0]))
+
+	f, err = SeasonalNaive{}.Forecast(history, params)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{4, 4, 4, 4, 4, 4}, f.Predicted)
+	assert.Greater(t, f.Upper[0], f.Predicted[0])
+
+	_, err = SeasonalNaive{Period: 90 * time.Second}.Forecast(history, params)
+	assert.EqualError(t, err, "period 1m30s must be a multiple of the interval 1m0s")
+	_, err = SeasonalNaive{Period: time.Hour}.Forecast(history, params)
+	assert.EqualError(t, err, "not enough data: need 60 points, have 8")
+	_, err = SeasonalNaive{}.Forecast(history, ForecastParams{Start: params.Start, End: params.End})
+	assert.EqualError(t, err, "interval must be positive")
+}
+
+func TestMovingAverage(t *testing.T) {
+	start := time.Unix(0, 0).UTC()
+	// The missing point is filled with the value before it.
+	history := regularSeries(start, 1, 2, 3, 4, math.NaN(), 8)
+	params := ForecastParams{Start: start.Add(6 * time.Minute), End: start.Add(7 * time.Minute), Interval: 60}
+
+	f, err := MovingAverage{Window: 3 * time.Minute}.Forecast(history, params)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{16.0 / 3, 16.0 / 3}, f.Predicted)
+	// The residuals against the mean of up to 3 values before each point are
+	// 1, 1.5, 2, 1 and 13/3.
+	assert.InDelta(t, baselineZ*math.Sqrt((1+2.25+4+1+169.0/9)/5), f.Upper[0]-f.Predicted[0], 1e-9)
+
+	f, err = MovingAverage{}.Forecast(history, params)
+	require.NoError(t, err)
+	assert.InDelta(t, 22.0/6, f.Predicted[0], 1e-9)
+
+	_, err = MovingAverage{Window: 30 * time.Second}.Forecast(history, params)
+	assert.Error(t, err)
+}
+
+func TestHoltWinters(t *testing.T) {
+	start := time.Unix(0, 0).UTC()
+	season := []float64{0, 2, 0, -2}
+	var additive, multiplicative []float64
+	for i := 0; i < 48; i++ {
+		additive = append(additive, 10+float64(i)+season[i%4])
+		multiplicative = append(multiplicative, (10+float64(i))*(1+season[i%4]/10))
+	}
+	params := ForecastParams{Start: start.Add(48 * time.Minute), End: start.Add(55 * time.Minute), Interval: 60}
+
+	f, err := HoltWinters{Period: 4 * time.Minute}.Forecast(regularSeries(start, additive...), params)
+	require.NoError(t, err)
+	require.Len(t, f.Predicted, 8)
+	for k, p := range f.Predicted {
+		i := 48 + k
+		assert.InDelta(t, 10+float64(i)+season[i%4], p, 0.5, "point %d", k)
+		assert.LessOrEqual(t, f.Lower[k], p)
+		assert.GreaterOrEqual(t, f.Upper[k], p)
+	}
+
+	f, err = HoltWinters{Period: 4 * time.Minute, Alpha: 0.5, Beta: 0.1, Gamma: 0.3, Multiplicative: true}.
+		Forecast(regularSeries(start, multiplicative...), params)
+	require.NoError(t, err)
+	for k, p := range f.Predicted {
+		i := 48 + k
+		want := (10 + float64(i)) * (1 + season[i%4]/10)
+		assert.InDelta(t, want, p, 0.05*want, "point %d", k)
+	}
+
+	_, err = HoltWinters{Period: 4 * time.Minute, Multiplicative: true}.Forecast(regularSeries(start, additive[:8]...), ForecastParams{
+		Start: start.Add(8 * time.Minute), End: start.Add(9 * time.Minute), Interval: 60,
+	})
+	require.NoError(t, err)
+	_, err = HoltWinters{Period: 4 * time.Minute, Multiplicative: true}.Forecast(regularSeries(start, 1, 0, 1, 1, 1, 1, 1, 1), ForecastParams{
+		Start: start.Add(8 * time.Minute), End: start.Add(9 * time.Minute), Interval: 60,
+	})
+	assert.EqualError(t, err, "multiplicative seasonality needs a positive series")
+	_, err = HoltWinters{Period: 4 * time.Minute}.Forecast(regularSeries(start, additive[:7]...), ForecastParams{
+		Start: start.Add(7 * time.Minute), End: start.Add(8 * time.Minute), Interval: 60,
+	})
+	assert.EqualError(t, err, "not enough data: need 8 points, have 7")
+	_, err = HoltWinters{Period: 4 * time.Minute, Alpha: 2}.Forecast(regularSeries(start, additive...), params)
+	assert.Error(t, err)
+}
+
+func TestBacktestBaseline(t *testing.T) {
+	start := time.Unix(0, 0).UTC()
+	var values []float64
+	for i := 0; i < 240; i++ {
+		values = append(values, float64(i%4))
+	}
+	series := []Series{regularSeries(start, values...)}
+
+	job := prometheusJob(t, "cpu", "cpu", "prom", "cpu")
+	job.Interval = 60
+	job.TrainingWindow = 3600
+	// The first window has no history before its cutoff.
+	windows := []BacktestWindow{
+		{Cutoff: start, End: start.Add(time.Hour)},
+		{Cutoff: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)},
+		{Cutoff: start.Add(3 * time.Hour), End: start.Add(3*time.Hour + 59*time.Minute)},
+	}
+
+	report, err := BacktestBaseline(context.Background(), job, SeasonalNaive{Period: 4 * time.Minute}, series, windows, BacktestOptions{})
+	require.EqualError(t, err, `window 1970-01-01T00:00:00Z: forecasting: series {__name__=cpu, pod=a}: not enough data: need 4 points, have 0`)
+	require.Len(t, report.Windows, 3)
+	assert.Error(t, report.Windows[0].Err)
+	assert.Equal(t, 61, report.Windows[1].Metrics.Points)
+	assert.Equal(t, 60, report.Windows[2].Metrics.Points)
+	assert.Equal(t, data.Labels{"pod": "a"}, report.Windows[1].Series[0].Labels)
+	assert.Equal(t, 121, report.Summary.Points)
+	assert.Zero(t, report.Summary.MAE)
+	assert.Equal(t, 1.0, report.Summary.Coverage)
+
+	report, err = BacktestBaseline(context.Background(), job, MovingAverage{Window: 4 * time.Minute}, series, windows[1:], BacktestOptions{})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, report.Summary.MAE, 0.01)
+}
+
+func TestClientBacktestBaseline(t *testing.T) {
+	// The series is 5 every minute.
+	var queries [][2]int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != dsQueryPath {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		body := struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		from, _ := strconv.ParseInt(body.From, 10, 64)
+		to, _ := strconv.ParseInt(body.To, 10, 64)
+		queries = append(queries, [2]int64{from, to})
+
+		var times []time.Time
+		var values []float64
+		for ts := from; ts <= to; ts += 60000 {
+			times = append(times, time.UnixMilli(ts))
+			values = append(values, 5)
+		}
+		resp := backend.NewQueryDataResponse()
+		resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+			data.NewFrame("",
+				data.NewField("Time", nil, times),
+				data.NewField("Value", data.Labels{nameLabel: "cpu"}, values),
+			),
+		}}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	job := prometheusJob(t, "cpu", "cpu", "prom", "cpu")
+	job.Interval = 60
+	job.TrainingWindow = 3600
+	windows := BacktestWindows(time.Unix(4*3600, 0), time.Hour, 1)
+	report, err := c.BacktestBaseline(context.Background(), job, MovingAverage{}, windows, BacktestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 61, report.Summary.Points)
+	assert.Zero(t, report.Summary.MAE)
+	assert.Equal(t, [][2]int64{
+		{2 * 3600 * 1000, 3 * 3600 * 1000},
+		{3 * 3600 * 1000, 4 * 3600 * 1000},
+	}, queries)
+}
@@ -0,0 +1,234 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBulkConcurrency is the number of requests a bulk operation runs in
+// parallel when BulkOptions.Concurrency is not set.
+const DefaultBulkConcurrency = 4
+
+// ErrSkipped is the error of bulk items that were not attempted because the
+// operation stopped early.
+var ErrSkipped = errors.New("skipped")
+
+// BulkOptions configure bulk operations.
+//
+// Requests made by bulk operations go through the client, so they are subject
+// to Config.RateLimit and Config.NumRetries. Set CheckLimits to also check
+// items against the series limits from TenantInfo before sending them.
+type BulkOptions struct {
+	// Concurrency is the number of items processed in parallel. Defaults to
+	// DefaultBulkConcurrency.
+	Concurrency int
+	// StopOnError stops starting new items after the first failure. Items
+	// already started run to completion; items not started fail with
+	// ErrSkipped.
+	StopOnError bool
+	// CheckLimits checks each job or outlier detector against the series
+	// limits from TenantInfo before it is created or updated, as PreflightJob
+	// and PreflightOutlierDetector do, and fails items exceeding them with a
+	// *SeriesLimitError without sending them. Tenant info is fetched once per
+	// operation. Each check runs the item's query, so it makes an extra
+	// datasource request per item. Resources without limits, such as
+	// holidays, are not checked.
+	CheckLimits bool
+
+	// Journal, if set, records the outcome of every item. Items the journal
+	// already records as succeeded for the same resource and operation are
+	// not attempted again; their results have Resumed set, and their Item is
+	// the input item with its ID set to the recorded one.
+	Journal *Journal
+	// Key returns the journal key of the item at index i. Defaults to the
+	// index, so set it if the input may change between runs, for example to
+	// the item's name.
+	Key func(i int) string
+}
+
+// BulkResult is the outcome of a single item of a bulk operation.
+type BulkResult[T any] struct {
+	// Index is the position of the item in the input.
+	Index int
+	// Item is the item returned by the server. For deletes it is the ID of the
+	// deleted item.
+	Item T
+	// Err is the error processing the item, if any.
+	Err error
+	// Resumed is set if the item was not attempted because the journal
+	// records it as succeeded by an earlier run.
+	Resumed bool
+}
+
+// BulkCreate creates items in parallel. It returns a result for every item, in
+// input order, and an error joining the errors of every failed item. If
+// opts.CheckLimits is set and the tenant info cannot be fetched, no item is
+// attempted and only the error is returned.
+func (r *Resource[T]) BulkCreate(ctx context.Context, items []T, opts BulkOptions) ([]BulkResult[T], error) {
+	create, err := r.withLimitCheck(ctx, opts, r.Create)
+	if err != nil {
+		return nil, err
+	}
+	return runBulk(ctx, r.path, "create", items, opts, create, r.ID, r.withID)
+}
+
+// BulkUpdate updates items in parallel. Results and errors are as for
+// BulkCreate.
+func (r *Resource[T]) BulkUpdate(ctx context.Context, items []T, opts BulkOptions) ([]BulkResult[T], error) {
+	update, err := r.withLimitCheck(ctx, opts, r.Update)
+	if err != nil {
+		return nil, err
+	}
+	return runBulk(ctx, r.path, "update", items, opts, update, r.ID, r.withID)
+}
+
+// withLimitCheck returns fn preceded by the Resource's series limit check, if
+// opts.CheckLimits is set and the Resource has one.
+func (r *Resource[T]) withLimitCheck(ctx context.Context, opts BulkOptions, fn func(context.Context, T) (T, error)) (func(context.Context, T) (T, error), error) {
+	if !opts.CheckLimits || r.limit == nil {
+		return fn, nil
+	}
+	info, err := r.client.TenantInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tenant info: %w", err)
+	}
+	return func(ctx context.Context, item T) (T, error) {
+		if err := r.limit(ctx, item, info); err != nil {
+			var zero T
+			return zero, err
+		}
+		return fn(ctx, item)
+	}, nil
+}
+
+// BulkDelete deletes the items with the given IDs in parallel. Results and
+// errors are as for BulkCreate.
+func (r *Resource[T]) BulkDelete(ctx context.Context, ids []string, opts BulkOptions) ([]BulkResult[string], error) {
+	identity := func(id string) string { return id }
+	return runBulk(ctx, r.path, "delete", ids, opts, func(ctx context.Context, id string) (string, error) {
+		return id, r.Delete(ctx, id)
+	}, identity, func(id, _ string) string { return id })
+}
+
+func (r *Resource[T]) withID(item T, id string) T {
+	*r.id(&item) = id
+	return item
+}
+
+// runBulk calls fn for every item. resource and op identify the operation in
+// the journal, idOf returns the ID of a result to record in it, and withID
+// rebuilds the result of an item completed by an earlier run from the recorded
+// ID; they are only used with a journal.
+func runBulk[In, Out any](
+	ctx context.Context,
+	resource, op string,
+	items []In,
+	opts BulkOptions,
+	fn func(context.Context, In) (Out, error),
+	idOf func(Out) string,
+	withID func(In, string) Out,
+) ([]BulkResult[Out], error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	// stop only gates dispatch: items already started keep running on ctx,
+	// so that their outcome, and their journal entry, reflect the server's.
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopped := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return ctx.Err() != nil
+		}
+	}
+
+	key := opts.Key
+	if key == nil {
+		key = strconv.Itoa
+	}
+	var (
+		journalMu   sync.Mutex
+		journalErrs []error
+	)
+	process := func(i int) BulkResult[Out] {
+		if opts.Journal == nil {
+			out, err := fn(ctx, items[i])
+			return BulkResult[Out]{Index: i, Item: out, Err: err}
+		}
+		k := key(i)
+		if e, ok := opts.Journal.completed(resource, op, k); ok {
+			return BulkResult[Out]{Index: i, Item: withID(items[i], e.ID), Resumed: true}
+		}
+		out, err := fn(ctx, items[i])
+		entry := JournalEntry{Time: time.Now().UTC(), Resource: resource, Op: op, Key: k}
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.ID = idOf(out)
+		}
+		if jerr := opts.Journal.record(entry); jerr != nil {
+			journalMu.Lock()
+			journalErrs = append(journalErrs, fmt.Errorf("item %d: %w", i, jerr))
+			journalMu.Unlock()
+		}
+		return BulkResult[Out]{Index: i, Item: out, Err: err}
+	}
+
+	results := make([]BulkResult[Out], len(items))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if stopped() {
+					results[i] = BulkResult[Out]{Index: i, Err: ErrSkipped}
+					continue
+				}
+				results[i] = process(i)
+				if results[i].Err != nil && opts.StopOnError {
+					stopOnce.Do(func() { close(stop) })
+				}
+			}
+		}()
+	}
+
+	for i := range items {
+		if !stopped() {
+			select {
+			case indexes <- i:
+				continue
+			case <-stop:
+			case <-ctx.Done():
+			}
+		}
+		results[i] = BulkResult[Out]{Index: i, Err: ErrSkipped}
+	}
+	close(indexes)
+	wg.Wait()
+
+	err := errors.Join(append([]error{bulkError(results)}, journalErrs...)...)
+	if ctx.Err() != nil {
+		err = errors.Join(err, ctx.Err())
+	}
+	return results, err
+}
+
+// bulkError joins the errors of failed items, leaving out skipped items.
+func bulkError[T any](results []BulkResult[T]) error {
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil && !errors.Is(res.Err, ErrSkipped) {
+			errs = append(errs, fmt.Errorf("item %d: %w", res.Index, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
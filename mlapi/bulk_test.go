@@ -0,0 +1,196 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkCreate(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		h := Holiday{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&h))
+		if h.Name == "bad" {
+			http.Error(w, "bad holiday", http.StatusBadRequest)
+			return
+		}
+		h.ID = "id-" + h.Name
+		_ = json.NewEncoder(w).Encode(responseWrapper[Holiday]{Data: h})
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	names := []string{"a", "b", "bad", "c", "d", "e", "f", "g"}
+	holidays := make([]Holiday, len(names))
+	for i, name := range names {
+		holidays[i] = Holiday{Name: name}
+	}
+
+	results, err := c.HolidayResource().BulkCreate(context.Background(), holidays, BulkOptions{Concurrency: 3})
+	require.EqualError(t, err, "item 2: status: 400, body: bad holiday\n")
+	require.Len(t, results, len(names))
+	for i, res := range results {
+		assert.Equal(t, i, res.Index)
+		if names[i] == "bad" {
+			assert.Error(t, res.Err)
+			continue
+		}
+		require.NoError(t, res.Err)
+		assert.Equal(t, "id-"+names[i], res.Item.ID)
+	}
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+}
+
+func TestBulkDeleteStopOnError(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/manage/api/v1/jobs/")
+		if id == "2" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, id)
+		mu.Unlock()
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	results, err := c.JobResource().BulkDelete(context.Background(), []string{"1", "2", "3", "4"}, BulkOptions{Concurrency: 1, StopOnError: true})
+	require.EqualError(t, err, "item 1: status: 404, body: not found\n")
+	assert.Equal(t, []string{"1"}, deleted)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[2].Err, ErrSkipped)
+	assert.ErrorIs(t, results[3].Err, ErrSkipped)
+}
+
+func TestBulkCreateStopOnErrorFinishesStarted(t *testing.T) {
+	failed := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := Holiday{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&h))
+		if h.Name == "bad" {
+			http.Error(w, "bad holiday", http.StatusBadRequest)
+			close(failed)
+			return
+		}
+		// Items started alongside the failing one only complete after it
+		// has failed.
+		<-failed
+		time.Sleep(20 * time.Millisecond)
+		h.ID = "id-" + h.Name
+		_ = json.NewEncoder(w).Encode(responseWrapper[Holiday]{Data: h})
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	holidays := []Holiday{{Name: "a"}, {Name: "b"}, {Name: "bad"}, {Name: "c"}, {Name: "d"}}
+	results, err := c.HolidayResource().BulkCreate(context.Background(), holidays, BulkOptions{Concurrency: 3, StopOnError: true})
+	require.EqualError(t, err, "item 2: status: 400, body: bad holiday\n")
+	for _, i := range []int{0, 1} {
+		require.NoError(t, results[i].Err)
+		assert.Equal(t, "id-"+holidays[i].Name, results[i].Item.ID)
+	}
+	assert.ErrorIs(t, results[3].Err, ErrSkipped)
+	assert.ErrorIs(t, results[4].Err, ErrSkipped)
+}
+
+func TestBulkCreateCheckLimits(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case pluginResourcesPath + "/tenant/api/v1/info":
+			_ = json.NewEncoder(w).Encode(responseWrapper[TenantInfo]{Data: TenantInfo{MaxSeriesPerJob: 2}})
+		case dsQueryPath:
+			body := struct {
+				Queries []map[string]interface{} `json:"queries"`
+			}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			frames := data.Frames{seriesFrame(data.Labels{"instance": "a"}, 1)}
+			if body.Queries[0]["expr"] == "big" {
+				frames = append(frames,
+					seriesFrame(data.Labels{"instance": "b"}, 1),
+					seriesFrame(data.Labels{"instance": "c"}, 1),
+				)
+			}
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: frames}
+			_ = json.NewEncoder(w).Encode(resp)
+		case pluginResourcesPath + "/manage/api/v1/jobs":
+			job := Job{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&job))
+			mu.Lock()
+			created = append(created, job.Name)
+			mu.Unlock()
+			job.ID = "id-" + job.Name
+			_ = json.NewEncoder(w).Encode(responseWrapper[Job]{Data: job})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL+pluginResourcesPath, Config{})
+	require.NoError(t, err)
+
+	jobs := []Job{prometheusJob(t, "small", "small", "prom", "small"), prometheusJob(t, "big", "big", "prom", "big")}
+	results, err := c.JobResource().BulkCreate(context.Background(), jobs, BulkOptions{CheckLimits: true})
+	require.EqualError(t, err, "item 1: job query returns 3 series, more than the limit of 2")
+	require.NoError(t, results[0].Err)
+	limitErr := &SeriesLimitError{}
+	require.ErrorAs(t, results[1].Err, &limitErr)
+	// The job over the limit is never sent.
+	assert.Equal(t, []string{"small"}, created)
+}
+
+func TestRateLimit(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{RateLimit: 100})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = c.JobResource().BulkDelete(context.Background(), []string{"1", "2", "3", "4"}, BulkOptions{})
+	require.NoError(t, err)
+	// Four requests at 100 per second need at least 30ms.
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
@@ -0,0 +1,287 @@
+package mlapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// DefaultForecastCacheTTL is how long cached forecasts are used, matching the
+// server's cache of ephemeral forecasts.
+const DefaultForecastCacheTTL = 24 * time.Hour
+
+// ForecastCache caches the responses of ForecastJob on the client, keyed by a
+// hash of the request, the API URL and the client's BearerToken or BasicAuth,
+// so that clients of different stacks or with different credentials sharing a
+// cache do not see each other's forecasts. Credentials added by a custom
+// Config.Client are not part of the key, so clients authenticating that way
+// must not share a cache. Set Config.ForecastCache to use it. Use
+// BypassForecastCache to skip the cache for a request.
+//
+// Responses where any query failed are not cached. Expired entries are removed
+// when they are looked up, and the whole cache is swept for them at most once
+// per TTL when a response is stored.
+//
+// A ForecastCache is safe for concurrent use, and a disk cache can be shared
+// by several processes.
+type ForecastCache struct {
+	ttl time.Duration
+	// dir is the directory of a disk cache, or empty for a memory cache.
+	dir string
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedForecast
+	swept   time.Time
+	stats   CacheStats
+}
+
+// CacheStats count the lookups of a ForecastCache.
+type CacheStats struct {
+	// Hits is the number of requests served from the cache.
+	Hits int
+	// Misses is the number of requests sent to the server, including those
+	// bypassing the cache.
+	Misses int
+	// Errors is the number of responses that could not be stored, or read
+	// back from disk. They do not fail the request.
+	Errors int
+}
+
+type cachedForecast struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Response json.RawMessage `json:"response"`
+}
+
+// NewMemoryForecastCache returns a cache holding forecasts in memory for ttl,
+// or DefaultForecastCacheTTL if ttl is zero.
+func NewMemoryForecastCache(ttl time.Duration) *ForecastCache {
+	if ttl <= 0 {
+		ttl = DefaultForecastCacheTTL
+	}
+	return &ForecastCache{ttl: ttl, now: time.Now, entries: map[string]cachedForecast{}}
+}
+
+// NewDiskForecastCache returns a cache storing forecasts as files in dir for
+// ttl, or DefaultForecastCacheTTL if ttl is zero. The directory is created if
+// it does not exist. It should only be used by the cache, as expired .json
+// and .tmp files in it are removed.
+func NewDiskForecastCache(dir string, ttl time.Duration) (*ForecastCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	c := NewMemoryForecastCache(ttl)
+	c.dir = dir
+	return c, nil
+}
+
+// Stats returns the lookup counts of the cache since it was created.
+func (c *ForecastCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+type bypassCacheKey struct{}
+
+// BypassForecastCache returns a context that makes ForecastJob skip the cache
+// and send the request to the server. The new response is still cached.
+func BypassForecastCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// forecastCacheKey returns a hash of the request, the API it is sent to and the
+// credentials it is sent with. baseURL includes any basic auth credentials.
+// Jobs are normalized and times converted to UTC, so that equivalent requests
+// share a key.
+func forecastCacheKey(baseURL, bearerToken string, spec ForecastRequest) (string, error) {
+	spec.Job = spec.Job.Normalize()
+	spec.ForecastParams.Start = spec.ForecastParams.Start.UTC()
+	spec.ForecastParams.End = spec.ForecastParams.End.UTC()
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(baseURL))
+	h.Write([]byte{0})
+	h.Write([]byte(bearerToken))
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// get returns the cached response for key, if there is one that has not
+// expired. It counts a miss otherwise, and removes an expired entry.
+func (c *ForecastCache) get(key string) (backend.QueryDataResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if c.dir != "" {
+		var err error
+		entry, ok, err = c.read(key)
+		if err != nil {
+			c.stats.Errors++
+		}
+	}
+	resp := backend.QueryDataResponse{}
+	if ok && c.now().Sub(entry.StoredAt) >= c.ttl {
+		c.remove(key)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return resp, false
+	}
+	if err := json.Unmarshal(entry.Response, &resp); err != nil {
+		c.stats.Errors++
+		c.stats.Misses++
+		return resp, false
+	}
+	c.stats.Hits++
+	return resp, true
+}
+
+// miss counts a request that bypassed the cache.
+func (c *ForecastCache) miss() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Misses++
+}
+
+// put caches resp for key, unless any of its queries failed.
+func (c *ForecastCache) put(key string, resp backend.QueryDataResponse) {
+	for _, r := range resp.Responses {
+		if r.Error != nil {
+			return
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweep()
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		c.stats.Errors++
+		return
+	}
+	entry := cachedForecast{StoredAt: c.now(), Response: data}
+	if c.dir == "" {
+		c.entries[key] = entry
+		return
+	}
+	if err := c.write(key, entry); err != nil {
+		c.stats.Errors++
+	}
+}
+
+// remove removes the entry for key. On disk, another process may have just
+// replaced an expired entry with a fresh one, in which case the fresh one is
+// lost; that only costs a miss.
+func (c *ForecastCache) remove(key string) {
+	if c.dir == "" {
+		delete(c.entries, key)
+		return
+	}
+	if err := os.Remove(filepath.Join(c.dir, key+".json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.stats.Errors++
+	}
+}
+
+// sweep removes every expired entry, at most once per TTL, so that entries
+// that are never looked up again do not accumulate. On disk, entries are
+// dated by their modification time, and temporary files left by interrupted
+// writes are removed too.
+func (c *ForecastCache) sweep() {
+	now := c.now()
+	if now.Sub(c.swept) < c.ttl {
+		return
+	}
+	c.swept = now
+
+	if c.dir == "" {
+		for key, entry := range c.entries {
+			if now.Sub(entry.StoredAt) >= c.ttl {
+				delete(c.entries, key)
+			}
+		}
+		return
+	}
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		c.stats.Errors++
+		return
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || (filepath.Ext(name) != ".json" && filepath.Ext(name) != ".tmp") {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			// Removed by another process.
+			continue
+		}
+		if now.Sub(info.ModTime()) >= c.ttl {
+			if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				c.stats.Errors++
+			}
+		}
+	}
+}
+
+func (c *ForecastCache) read(key string) (cachedForecast, bool, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return cachedForecast{}, false, nil
+	}
+	if err != nil {
+		return cachedForecast{}, false, err
+	}
+	entry := cachedForecast{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cachedForecast{}, false, err
+	}
+	return entry, true, nil
+}
+
+// write stores an entry through a temporary file, so that other processes
+// never read a partial entry.
+func (c *ForecastCache) write(key string, entry cachedForecast) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		//nolint:errcheck // The write error is more relevant.
+		f.Close()
+		//nolint:errcheck // The temporary file is abandoned either way.
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		//nolint:errcheck // The temporary file is abandoned either way.
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(c.dir, key+".json"))
+}
@@ -0,0 +1,220 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newForecastServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/predict/api/v1/forecast" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		*requests++
+		resp := backend.NewQueryDataResponse()
+		resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+			data.NewFrame("",
+				data.NewField("time", nil, []time.Time{time.Unix(0, 0)}),
+				data.NewField("yhat", nil, []float64{float64(*requests)}),
+			),
+		}}
+		_ = json.NewEncoder(w).Encode(responseWrapper[*backend.QueryDataResponse]{Data: resp})
+	}))
+}
+
+func predicted(t *testing.T, resp backend.QueryDataResponse) float64 {
+	t.Helper()
+	forecasts, err := DecodeForecasts(resp)
+	require.NoError(t, err)
+	require.Len(t, forecasts["A"], 1)
+	return forecasts["A"][0].Predicted[0]
+}
+
+func TestForecastCache(t *testing.T) {
+	var requests int
+	s := newForecastServer(t, &requests)
+	defer s.Close()
+
+	cache := NewMemoryForecastCache(0)
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+	c, err := New(s.URL, Config{ForecastCache: cache})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	spec := ForecastRequest{
+		Job:            prometheusJob(t, "cpu", "cpu", "prom", "up"),
+		ForecastParams: ForecastParams{Start: start, End: start.Add(time.Hour), Interval: 60},
+	}
+
+	resp, err := c.ForecastJob(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, predicted(t, resp))
+
+	// The same request in another time zone is served from the cache.
+	spec.ForecastParams.Start = start.In(time.FixedZone("CET", 3600))
+	resp, err = c.ForecastJob(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, predicted(t, resp))
+
+	resp, err = c.ForecastJob(BypassForecastCache(ctx), spec)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, predicted(t, resp))
+
+	now = now.Add(DefaultForecastCacheTTL)
+	resp, err = c.ForecastJob(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, predicted(t, resp))
+
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 3}, cache.Stats())
+}
+
+func TestDiskForecastCache(t *testing.T) {
+	var requests int
+	s := newForecastServer(t, &requests)
+	defer s.Close()
+
+	dir := t.TempDir()
+	spec := ForecastRequest{Job: prometheusJob(t, "cpu", "cpu", "prom", "up")}
+	for i := range 2 {
+		// Each client stands in for a separate process sharing the directory.
+		cache, err := NewDiskForecastCache(dir, time.Hour)
+		require.NoError(t, err)
+		c, err := New(s.URL, Config{ForecastCache: cache})
+		require.NoError(t, err)
+
+		resp, err := c.ForecastJob(context.Background(), spec)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, predicted(t, resp))
+		if i == 0 {
+			assert.Equal(t, CacheStats{Misses: 1}, cache.Stats())
+		} else {
+			assert.Equal(t, CacheStats{Hits: 1}, cache.Stats())
+		}
+	}
+	assert.Equal(t, 1, requests)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestForecastCacheEviction(t *testing.T) {
+	cache := NewMemoryForecastCache(time.Hour)
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+	resp := backend.NewQueryDataResponse()
+
+	cache.put("a", *resp)
+	cache.put("b", *resp)
+	now = now.Add(time.Hour)
+
+	// An expired lookup removes the entry.
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+	assert.NotContains(t, cache.entries, "a")
+
+	// Storing a response sweeps out entries that are never looked up again.
+	cache.put("c", *resp)
+	assert.Len(t, cache.entries, 1)
+	assert.Contains(t, cache.entries, "c")
+}
+
+func TestDiskForecastCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskForecastCache(dir, time.Hour)
+	require.NoError(t, err)
+	resp := backend.NewQueryDataResponse()
+
+	// Left by an interrupted write, and by an earlier process.
+	stale := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"x.123.tmp", "y.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, name), stale, stale))
+	}
+	// Not the cache's.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "notes.txt"), stale, stale))
+
+	cache.put("a", *resp)
+	assert.ElementsMatch(t, []string{"a.json", "notes.txt"}, dirNames(t, dir))
+
+	cache.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"notes.txt"}, dirNames(t, dir))
+	assert.Equal(t, CacheStats{Misses: 1}, cache.Stats())
+}
+
+func TestForecastCacheSkipsErrors(t *testing.T) {
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		resp := backend.NewQueryDataResponse()
+		resp.Responses["A"] = backend.ErrDataResponse(backend.StatusBadRequest, "query failed")
+		_ = json.NewEncoder(w).Encode(responseWrapper[*backend.QueryDataResponse]{Data: resp})
+	}))
+	defer s.Close()
+
+	cache := NewMemoryForecastCache(0)
+	c, err := New(s.URL, Config{ForecastCache: cache})
+	require.NoError(t, err)
+	spec := ForecastRequest{Job: prometheusJob(t, "cpu", "cpu", "prom", "up")}
+	for range 2 {
+		resp, err := c.ForecastJob(context.Background(), spec)
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+	}
+	assert.Equal(t, 2, requests)
+	assert.Empty(t, cache.entries)
+}
+
+func TestForecastCacheKeyedByCredentials(t *testing.T) {
+	var requests int
+	s := newForecastServer(t, &requests)
+	defer s.Close()
+
+	cache := NewMemoryForecastCache(0)
+	spec := ForecastRequest{Job: prometheusJob(t, "cpu", "cpu", "prom", "up")}
+	for _, cfg := range []Config{
+		{BearerToken: "tenant-a"},
+		{BearerToken: "tenant-b"},
+		{BasicAuth: url.UserPassword("1", "secret")},
+		{BasicAuth: url.UserPassword("2", "secret")},
+		{BearerToken: "tenant-a"},
+	} {
+		cfg.ForecastCache = cache
+		c, err := New(s.URL, cfg)
+		require.NoError(t, err)
+		_, err = c.ForecastJob(context.Background(), spec)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, requests)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 4}, cache.Stats())
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
@@ -19,6 +19,8 @@ type responseWrapper[T any] struct {
 	Data     T        `json:"data"`
 	Warnings []string `json:"warnings"`
 	Error    string   `json:"error"`
+	// NextCursor is set on paginated list responses that have more results.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // Client is a Grafana API client.
@@ -26,6 +28,7 @@ type Client struct {
 	config  Config
 	baseURL url.URL
 	client  *http.Client
+	limiter *rateLimiter
 }
 
 // Config contains client configuration.
@@ -38,6 +41,18 @@ type Config struct {
 	Client *http.Client
 	// NumRetries contains the number of attempted retries
 	NumRetries int
+	// ValidateJobs enables client-side validation of jobs before they are
+	// created or updated. See Job.Validate.
+	ValidateJobs bool
+	// RateLimit is the maximum number of requests per second sent by the
+	// client, including retries. Zero means unlimited.
+	RateLimit float64
+	// GrafanaURL is the URL of the Grafana instance, used for datasource
+	// queries through /api/ds/query. Defaults to the base URL with the
+	// machine learning plugin's resource path removed.
+	GrafanaURL string
+	// ForecastCache, if set, caches the responses of ForecastJob.
+	ForecastCache *ForecastCache
 }
 
 // New creates a new Grafana client.
@@ -60,6 +75,7 @@ func New(baseURL string, cfg Config) (*Client, error) {
 		config:  cfg,
 		baseURL: *u,
 		client:  cli,
+		limiter: newRateLimiter(cfg.RateLimit),
 	}, nil
 }
 
@@ -95,6 +111,9 @@ func (c *Client) request(ctx context.Context, method, requestPath string, query
 		if n != 0 {
 			time.Sleep(time.Second * 5)
 		}
+		if err = c.limiter.wait(ctx); err != nil {
+			return err
+		}
 
 		resp, err = c.client.Do(req)
 
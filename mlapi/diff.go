@@ -0,0 +1,176 @@
+package mlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeOp is the kind of change made to a field.
+type ChangeOp string
+
+const (
+	// ChangeOpAdd means the field is only present in the new value.
+	ChangeOpAdd ChangeOp = "add"
+	// ChangeOpRemove means the field is only present in the old value.
+	ChangeOpRemove ChangeOp = "remove"
+	// ChangeOpReplace means the field is present in both values but differs.
+	ChangeOpReplace ChangeOp = "replace"
+)
+
+// Change is a difference in a single field between two versions of a
+// resource.
+type Change struct {
+	Op ChangeOp
+	// Path is the path to the field using JSON field names. Keys of map fields
+	// such as HyperParams or Labels are separate elements, for example
+	// ["hyperParams", "growth"].
+	Path []string
+	// Old is the old value of the field, or nil if it was added. Values use
+	// the types produced by decoding JSON into an interface{}.
+	Old interface{}
+	// New is the new value of the field, or nil if it was removed.
+	New interface{}
+}
+
+// Changes is a list of field changes, ordered by path.
+type Changes []Change
+
+// String renders the changes as human readable text, one change per line.
+func (c Changes) String() string {
+	var sb strings.Builder
+	for _, change := range c {
+		p := strings.Join(change.Path, ".")
+		switch change.Op {
+		case ChangeOpAdd:
+			fmt.Fprintf(&sb, "+ %s: %s\n", p, diffValue(change.New))
+		case ChangeOpRemove:
+			fmt.Fprintf(&sb, "- %s: %s\n", p, diffValue(change.Old))
+		default:
+			fmt.Fprintf(&sb, "~ %s: %s => %s\n", p, diffValue(change.Old), diffValue(change.New))
+		}
+	}
+	return sb.String()
+}
+
+func diffValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// JSONPatch renders the changes as an RFC 6902 JSON patch that transforms the
+// old value into the new one.
+func (c Changes) JSONPatch() ([]byte, error) {
+	ops := make([]map[string]interface{}, len(c))
+	for i, change := range c {
+		var sb strings.Builder
+		for _, elem := range change.Path {
+			sb.WriteString("/")
+			sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(elem))
+		}
+		ops[i] = map[string]interface{}{"op": change.Op, "path": sb.String()}
+		if change.Op != ChangeOpRemove {
+			ops[i]["value"] = change.New
+		}
+	}
+	return json.Marshal(ops)
+}
+
+// DiffJob returns the changes UpdateJob would make when updating the job
+// actual to desired. Fields populated by the server are ignored.
+func DiffJob(actual, desired Job) (Changes, error) {
+	return diffResources(actual, desired, "id", "paused")
+}
+
+// DiffOutlierDetector returns the changes between two versions of an outlier
+// detector. Fields populated by the server are ignored.
+func DiffOutlierDetector(actual, desired OutlierDetector) (Changes, error) {
+	return diffResources(actual, desired, "id", "paused")
+}
+
+// DiffHoliday returns the changes between two versions of a holiday. Fields
+// populated by the server are ignored.
+func DiffHoliday(actual, desired Holiday) (Changes, error) {
+	return diffResources(actual, desired, "id")
+}
+
+// DiffAlert returns the changes between two versions of an alert. Fields
+// populated by the server are ignored.
+func DiffAlert(actual, desired Alert) (Changes, error) {
+	return diffResources(actual, desired, "id", "syncError")
+}
+
+// diffResources compares the JSON representations of two values, so that
+// paths match the field names used by the API.
+func diffResources(actual, desired any, ignore ...string) (Changes, error) {
+	a, err := toJSONValue(actual)
+	if err != nil {
+		return nil, err
+	}
+	d, err := toJSONValue(desired)
+	if err != nil {
+		return nil, err
+	}
+	am, _ := a.(map[string]interface{})
+	dm, _ := d.(map[string]interface{})
+	for _, field := range ignore {
+		delete(am, field)
+		delete(dm, field)
+	}
+	var changes Changes
+	diffMaps(nil, am, dm, &changes)
+	return changes, nil
+}
+
+func toJSONValue(v any) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// diffMaps appends the changes between two JSON objects. Nested objects are
+// compared key by key; any other values, including arrays, are compared as a
+// whole.
+func diffMaps(prefix []string, before, after map[string]interface{}, changes *Changes) {
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := append(append([]string{}, prefix...), k)
+		ov, inOld := before[k]
+		nv, inNew := after[k]
+		switch {
+		case !inOld:
+			*changes = append(*changes, Change{Op: ChangeOpAdd, Path: p, New: nv})
+		case !inNew:
+			*changes = append(*changes, Change{Op: ChangeOpRemove, Path: p, Old: ov})
+		default:
+			om, oIsMap := ov.(map[string]interface{})
+			nm, nIsMap := nv.(map[string]interface{})
+			if oIsMap && nIsMap {
+				diffMaps(p, om, nm, changes)
+			} else if !reflect.DeepEqual(ov, nv) {
+				*changes = append(*changes, Change{Op: ChangeOpReplace, Path: p, Old: ov, New: nv})
+			}
+		}
+	}
+}
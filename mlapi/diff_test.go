@@ -0,0 +1,83 @@
+package mlapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffJob(t *testing.T) {
+	actual := Job{
+		ID:          "8b154ff8-3d64-4b79-8b26-02b4baeb44e4",
+		Name:        "Test Job",
+		Metric:      "test_job",
+		Interval:    300,
+		HyperParams: map[string]interface{}{"growth": "linear", "interval_width": 0.95},
+		CustomLabels: map[string]interface{}{
+			"team": "a",
+			"env":  "prod",
+		},
+	}
+	desired := actual
+	desired.ID = ""
+	desired.Interval = 600
+	desired.HyperParams = map[string]interface{}{"growth": "flat", "interval_width": 0.95, "changepoint_prior_scale": 0.1}
+	desired.CustomLabels = map[string]interface{}{"team": "a"}
+
+	changes, err := DiffJob(actual, desired)
+	require.NoError(t, err)
+	assert.Equal(t, Changes{
+		{Op: ChangeOpRemove, Path: []string{"customLabels", "env"}, Old: "prod"},
+		{Op: ChangeOpAdd, Path: []string{"hyperParams", "changepoint_prior_scale"}, New: 0.1},
+		{Op: ChangeOpReplace, Path: []string{"hyperParams", "growth"}, Old: "linear", New: "flat"},
+		{Op: ChangeOpReplace, Path: []string{"interval"}, Old: float64(300), New: float64(600)},
+	}, changes)
+
+	assert.Equal(t, `- customLabels.env: "prod"
++ hyperParams.changepoint_prior_scale: 0.1
+~ hyperParams.growth: "linear" => "flat"
+~ interval: 300 => 600
+`, changes.String())
+
+	patch, err := changes.JSONPatch()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op":"remove","path":"/customLabels/env"},
+		{"op":"add","path":"/hyperParams/changepoint_prior_scale","value":0.1},
+		{"op":"replace","path":"/hyperParams/growth","value":"flat"},
+		{"op":"replace","path":"/interval","value":600}
+	]`, string(patch))
+
+	changes, err = DiffJob(actual, actual)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffAlert(t *testing.T) {
+	actual := Alert{
+		ID:          "5218f38f-569b-448f-b81d-578173412195",
+		Title:       "test job alert",
+		For:         model.Duration(5 * time.Minute),
+		Annotations: map[string]string{"summary": "Anomaly detected"},
+		SyncError:   "failed to sync",
+	}
+	desired := Alert{
+		Title:       "test job alert",
+		For:         model.Duration(10 * time.Minute),
+		Annotations: map[string]string{"summary": "Anomaly detected", "a/b": "c"},
+	}
+
+	changes, err := DiffAlert(actual, desired)
+	require.NoError(t, err)
+	assert.Equal(t, "+ annotations.a/b: \"c\"\n~ for: \"5m\" => \"10m\"\n", changes.String())
+
+	patch, err := changes.JSONPatch()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op":"add","path":"/annotations/a~1b","value":"c"},
+		{"op":"replace","path":"/for","value":"10m"}
+	]`, string(patch))
+}
@@ -0,0 +1,131 @@
+package mlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	dsQueryPath = "/api/ds/query"
+	// pluginResourcesPath is the path of the machine learning API below the
+	// Grafana URL.
+	pluginResourcesPath = "/api/plugins/grafana-ml-app/resources"
+)
+
+// datasourceQuery is a single query sent to a datasource through Grafana.
+type datasourceQuery struct {
+	datasourceUID  string
+	datasourceID   uint
+	datasourceType string
+	// model is the datasource specific query, as stored in QueryParams.
+	model    map[string]interface{}
+	from, to time.Time
+	interval time.Duration
+}
+
+// grafana returns a client for the Grafana instance, sharing the HTTP client,
+// credentials and rate limit of c.
+func (c *Client) grafana() (*Client, error) {
+	g := *c
+	if c.config.GrafanaURL == "" {
+		g.baseURL.Path = strings.TrimSuffix(strings.TrimSuffix(g.baseURL.Path, "/"), pluginResourcesPath)
+		return &g, nil
+	}
+	u, err := url.Parse(c.config.GrafanaURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Grafana URL: %w", err)
+	}
+	if c.config.BasicAuth != nil {
+		u.User = c.config.BasicAuth
+	}
+	g.baseURL = *u
+	return &g, nil
+}
+
+// queryDatasource runs q through Grafana's /api/ds/query and returns the
+// frames of its response.
+func (c *Client) queryDatasource(ctx context.Context, q datasourceQuery) (data.Frames, error) {
+	g, err := c.grafana()
+	if err != nil {
+		return nil, err
+	}
+
+	query := make(map[string]interface{}, len(q.model)+4)
+	for k, v := range q.model {
+		query[k] = v
+	}
+	refID, _ := query["refId"].(string)
+	if refID == "" {
+		refID = defaultRefID
+		query["refId"] = refID
+	}
+	if q.datasourceUID != "" {
+		query["datasource"] = map[string]string{"uid": q.datasourceUID, "type": q.datasourceType}
+	} else {
+		query["datasourceId"] = q.datasourceID
+	}
+	if q.interval > 0 {
+		query["intervalMs"] = q.interval.Milliseconds()
+		query["maxDataPoints"] = int64(q.to.Sub(q.from) / q.interval)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"from":    strconv.FormatInt(q.from.UnixMilli(), 10),
+		"to":      strconv.FormatInt(q.to.UnixMilli(), 10),
+		"queries": []interface{}{query},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := backend.QueryDataResponse{}
+	if err := g.request(ctx, "POST", dsQueryPath, nil, bytes.NewReader(body), &result); err != nil {
+		return nil, err
+	}
+	resp, ok := result.Responses[refID]
+	if !ok {
+		return nil, fmt.Errorf("no response for query %s", refID)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("query %s: %w", refID, resp.Error)
+	}
+	return resp.Frames, nil
+}
+
+// seriesLabels returns the distinct label sets of the value fields of frames,
+// sorted by their string form. Each numeric field is one series; time, string
+// and other fields are not.
+func seriesLabels(frames data.Frames) []data.Labels {
+	seen := map[string]bool{}
+	var series []data.Labels
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+			labels := field.Labels
+			if labels == nil {
+				labels = data.Labels{}
+			}
+			key := labels.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			series = append(series, labels)
+		}
+	}
+	sort.Slice(series, func(a, b int) bool {
+		return series[a].String() < series[b].String()
+	})
+	return series
+}
@@ -0,0 +1,177 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when looking up a resource by name finds
+	// nothing.
+	ErrNotFound = errors.New("not found")
+	// ErrAmbiguousName is returned when looking up a resource by name finds
+	// more than one.
+	ErrAmbiguousName = errors.New("ambiguous name")
+)
+
+// JobByName fetches the machine learning job with the given name. It returns
+// ErrNotFound if there is none and ErrAmbiguousName if there is more than one.
+func (c *Client) JobByName(ctx context.Context, name string) (Job, error) {
+	return byName(ctx, c.JobResource(), "job", name, func(j Job) string { return j.Name })
+}
+
+// OutlierDetectorByName fetches the outlier detector with the given name. It
+// returns ErrNotFound if there is none and ErrAmbiguousName if there is more
+// than one.
+func (c *Client) OutlierDetectorByName(ctx context.Context, name string) (OutlierDetector, error) {
+	return byName(ctx, c.OutlierDetectorResource(), "outlier detector", name, func(o OutlierDetector) string { return o.Name })
+}
+
+// HolidayByName fetches the holiday with the given name. It returns
+// ErrNotFound if there is none and ErrAmbiguousName if there is more than one.
+func (c *Client) HolidayByName(ctx context.Context, name string) (Holiday, error) {
+	return byName(ctx, c.HolidayResource(), "holiday", name, func(h Holiday) string { return h.Name })
+}
+
+func byName[T any](ctx context.Context, r *Resource[T], kind, name string, nameOf func(T) string) (T, error) {
+	var (
+		zero    T
+		matches []T
+	)
+	for item, err := range r.All(ctx, ListOptions{NamePrefix: name}) {
+		if err != nil {
+			return zero, err
+		}
+		if nameOf(item) == name {
+			matches = append(matches, item)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return zero, fmt.Errorf("%s %q: %w", kind, name, ErrNotFound)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, len(matches))
+		for i, m := range matches {
+			ids[i] = r.ID(m)
+		}
+		return zero, fmt.Errorf("%s %q matches %v: %w", kind, name, ids, ErrAmbiguousName)
+	}
+}
+
+// EnsureAction is the action taken by an Ensure method.
+type EnsureAction string
+
+const (
+	// EnsureCreated means the resource did not exist and was created.
+	EnsureCreated EnsureAction = "created"
+	// EnsureUpdated means the resource existed but differed, and was updated.
+	EnsureUpdated EnsureAction = "updated"
+	// EnsureUnchanged means the resource already matched and was left alone.
+	EnsureUnchanged EnsureAction = "unchanged"
+)
+
+// EnsureJob creates or updates a machine learning job so that it matches job.
+// The existing job is found by ID if job.ID is set, otherwise by name. It is
+// only updated, and so retrained, if it is not SemanticEqual to job. Holidays
+// given by name are resolved to IDs first, as the API returns IDs.
+func (c *Client) EnsureJob(ctx context.Context, job Job) (Job, EnsureAction, error) {
+	job, err := c.ResolveHolidays(ctx, job)
+	if err != nil {
+		return Job{}, "", err
+	}
+	return ensure(ctx, c.JobResource(), job, c.JobByName, job.Name, Job.SemanticEqual, c.NewJob, c.UpdateJob)
+}
+
+// ResolveHolidays returns a copy of the job with the holidays given by name
+// replaced by their IDs, so that it compares equal to the job returned by the
+// API. Entries that are holiday IDs are kept. It returns ErrNotFound for an
+// entry that is neither, and ErrAmbiguousName for a name shared by several
+// holidays.
+func (c *Client) ResolveHolidays(ctx context.Context, job Job) (Job, error) {
+	if len(job.Holidays) == 0 {
+		return job, nil
+	}
+	holidays, err := c.Holidays(ctx)
+	if err != nil {
+		return Job{}, err
+	}
+	ids := map[string]bool{}
+	byName := map[string][]string{}
+	for _, h := range holidays {
+		ids[h.ID] = true
+		byName[h.Name] = append(byName[h.Name], h.ID)
+	}
+
+	resolved := make([]string, len(job.Holidays))
+	for i, holiday := range job.Holidays {
+		switch matches := byName[holiday]; {
+		case ids[holiday]:
+			resolved[i] = holiday
+		case len(matches) == 1:
+			resolved[i] = matches[0]
+		case len(matches) == 0:
+			return Job{}, fmt.Errorf("holiday %q: %w", holiday, ErrNotFound)
+		default:
+			return Job{}, fmt.Errorf("holiday %q matches %v: %w", holiday, matches, ErrAmbiguousName)
+		}
+	}
+	job.Holidays = resolved
+	return job, nil
+}
+
+// EnsureOutlierDetector creates or updates an outlier detector so that it
+// matches outlier. See EnsureJob.
+func (c *Client) EnsureOutlierDetector(ctx context.Context, outlier OutlierDetector) (OutlierDetector, EnsureAction, error) {
+	return ensure(ctx, c.OutlierDetectorResource(), outlier, c.OutlierDetectorByName, outlier.Name, OutlierDetector.SemanticEqual, c.NewOutlierDetector, c.UpdateOutlierDetector)
+}
+
+// EnsureHoliday creates or updates a holiday so that it matches holiday. See
+// EnsureJob.
+func (c *Client) EnsureHoliday(ctx context.Context, holiday Holiday) (Holiday, EnsureAction, error) {
+	return ensure(ctx, c.HolidayResource(), holiday, c.HolidayByName, holiday.Name, Holiday.SemanticEqual, c.NewHoliday, c.UpdateHoliday)
+}
+
+func ensure[T any](
+	ctx context.Context,
+	r *Resource[T],
+	desired T,
+	lookup func(context.Context, string) (T, error),
+	name string,
+	equal func(T, T) bool,
+	create, update func(context.Context, T) (T, error),
+) (T, EnsureAction, error) {
+	var (
+		zero     T
+		existing T
+		err      error
+	)
+	if id := r.ID(desired); id != "" {
+		existing, err = r.Get(ctx, id)
+	} else {
+		existing, err = lookup(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			created, err := create(ctx, desired)
+			if err != nil {
+				return zero, "", err
+			}
+			return created, EnsureCreated, nil
+		}
+	}
+	if err != nil {
+		return zero, "", err
+	}
+
+	if equal(existing, desired) {
+		return existing, EnsureUnchanged, nil
+	}
+	*r.id(&desired) = r.ID(existing)
+	updated, err := update(ctx, desired)
+	if err != nil {
+		return zero, "", err
+	}
+	return updated, EnsureUpdated, nil
+}
@@ -0,0 +1,133 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobByName(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/manage/api/v1/jobs" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(responseWrapper[[]Job]{Data: []Job{
+			{ID: "1", Name: "cpu"},
+			{ID: "2", Name: "cpu usage"},
+			{ID: "3", Name: "mem"},
+			{ID: "4", Name: "mem"},
+		}})
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	job, err := c.JobByName(ctx, "cpu")
+	require.NoError(t, err)
+	assert.Equal(t, "1", job.ID)
+
+	_, err = c.JobByName(ctx, "disk")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = c.JobByName(ctx, "mem")
+	require.ErrorIs(t, err, ErrAmbiguousName)
+	require.EqualError(t, err, `job "mem" matches [3 4]: ambiguous name`)
+}
+
+func TestEnsureHoliday(t *testing.T) {
+	holidays := map[string]Holiday{}
+	var writes int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/manage/api/v1/holidays":
+			all := []Holiday{}
+			for _, h := range holidays {
+				all = append(all, h)
+			}
+			_ = enc.Encode(responseWrapper[[]Holiday]{Data: all})
+		case r.Method == "POST":
+			writes++
+			h := Holiday{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&h))
+			h.ID = "6d2d261c-7efc-4106-832c-751ba4bda77e"
+			holidays[h.ID] = h
+			_ = enc.Encode(responseWrapper[Holiday]{Data: h})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	holiday := Holiday{Name: "Test Holiday", Jobs: []string{"b", "a"}}
+
+	created, action, err := c.EnsureHoliday(ctx, holiday)
+	require.NoError(t, err)
+	assert.Equal(t, EnsureCreated, action)
+	assert.Equal(t, "6d2d261c-7efc-4106-832c-751ba4bda77e", created.ID)
+
+	// Reordered jobs are not a change.
+	holiday.Jobs = []string{"a", "b"}
+	_, action, err = c.EnsureHoliday(ctx, holiday)
+	require.NoError(t, err)
+	assert.Equal(t, EnsureUnchanged, action)
+
+	holiday.Description = "changed"
+	updated, action, err := c.EnsureHoliday(ctx, holiday)
+	require.NoError(t, err)
+	assert.Equal(t, EnsureUpdated, action)
+	assert.Equal(t, "changed", updated.Description)
+	assert.Equal(t, 2, writes)
+}
+
+func TestEnsureJobResolvesHolidayNames(t *testing.T) {
+	existing := Job{ID: "j1", Name: "cpu", Metric: "cpu", Holidays: []string{"h1", "h2"}}
+	var writes int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/manage/api/v1/holidays":
+			_ = enc.Encode(responseWrapper[[]Holiday]{Data: []Holiday{
+				{ID: "h1", Name: "Christmas"},
+				{ID: "h2", Name: "Easter"},
+				{ID: "h3", Name: "Sale"},
+				{ID: "h4", Name: "Sale"},
+			}})
+		case r.Method == "GET" && r.URL.Path == "/manage/api/v1/jobs":
+			_ = enc.Encode(responseWrapper[[]Job]{Data: []Job{existing}})
+		case r.Method == "POST":
+			writes++
+			http.Error(w, "unexpected write", http.StatusBadRequest)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Holidays may be given by name or ID.
+	_, action, err := c.EnsureJob(ctx, Job{Name: "cpu", Metric: "cpu", Holidays: []string{"Easter", "h1"}})
+	require.NoError(t, err)
+	assert.Equal(t, EnsureUnchanged, action)
+	assert.Zero(t, writes)
+
+	_, _, err = c.EnsureJob(ctx, Job{Name: "cpu", Metric: "cpu", Holidays: []string{"Sale"}})
+	require.ErrorIs(t, err, ErrAmbiguousName)
+	_, _, err = c.EnsureJob(ctx, Job{Name: "cpu", Metric: "cpu", Holidays: []string{"Halloween"}})
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,391 @@
+package mlapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ExportFormat is a file format forecasts can be exported to.
+type ExportFormat string
+
+// Export formats.
+const (
+	// ExportCSV writes CSV with a header row.
+	ExportCSV ExportFormat = "csv"
+	// ExportNDJSON writes one JSON object per row, one per line.
+	ExportNDJSON ExportFormat = "ndjson"
+	// ExportArrow writes an Arrow IPC file.
+	ExportArrow ExportFormat = "arrow"
+	// ExportParquet writes a Parquet file.
+	ExportParquet ExportFormat = "parquet"
+)
+
+// Column names of exported forecasts. Labels are exported as one column per
+// label name, prefixed with ExportLabelPrefix.
+const (
+	ExportTimeColumn      = "time"
+	ExportActualColumn    = "actual"
+	ExportPredictedColumn = "predicted"
+	ExportLowerColumn     = "lower"
+	ExportUpperColumn     = "upper"
+	ExportLabelPrefix     = "label_"
+)
+
+// ExportForecasts writes forecasts to w in long format: one row per series and
+// timestamp, with the columns time, actual, predicted, lower and upper
+// followed by a label_<name> column for every label name, in name order.
+//
+// Times are in UTC: RFC 3339 strings in CSV and NDJSON, and millisecond
+// timestamps in Arrow and Parquet. Missing values are null, or empty in CSV.
+func ExportForecasts(w io.Writer, format ExportFormat, forecasts []Forecast) error {
+	return writeTable(w, format, forecastTable(forecasts))
+}
+
+// ExportFrame writes the fields of a data frame to w, one column per field.
+// Columns are named after their field, with the field's labels appended in
+// braces. Times and missing values are handled as for ExportForecasts. It
+// returns an error if the fields have different lengths.
+func ExportFrame(w io.Writer, format ExportFormat, frame *data.Frame) error {
+	t, err := frameTable(frame)
+	if err != nil {
+		return err
+	}
+	return writeTable(w, format, t)
+}
+
+type columnKind int
+
+const (
+	kindTime columnKind = iota
+	kindFloat
+	kindString
+	kindBool
+)
+
+// exportColumn is a column of an exported table. Values are time.Time,
+// float64, string or bool according to kind, or nil if missing.
+type exportColumn struct {
+	name   string
+	kind   columnKind
+	values []interface{}
+}
+
+type exportTable struct {
+	columns []exportColumn
+	rows    int
+}
+
+func forecastTable(forecasts []Forecast) exportTable {
+	names := map[string]bool{}
+	rows := 0
+	for _, f := range forecasts {
+		for name := range f.Labels {
+			names[name] = true
+		}
+		rows += len(f.Timestamps)
+	}
+	labelNames := make([]string, 0, len(names))
+	for name := range names {
+		labelNames = append(labelNames, name)
+	}
+	sort.Strings(labelNames)
+
+	columns := []exportColumn{
+		{name: ExportTimeColumn, kind: kindTime},
+		{name: ExportActualColumn, kind: kindFloat},
+		{name: ExportPredictedColumn, kind: kindFloat},
+		{name: ExportLowerColumn, kind: kindFloat},
+		{name: ExportUpperColumn, kind: kindFloat},
+	}
+	for _, name := range labelNames {
+		columns = append(columns, exportColumn{name: ExportLabelPrefix + name, kind: kindString})
+	}
+	for i := range columns {
+		columns[i].values = make([]interface{}, 0, rows)
+	}
+
+	for _, f := range forecasts {
+		for i, t := range f.Timestamps {
+			columns[0].values = append(columns[0].values, t.UTC())
+			for c, values := range [][]float64{f.Actual, f.Predicted, f.Lower, f.Upper} {
+				columns[c+1].values = append(columns[c+1].values, floatValue(values, i))
+			}
+			for l, name := range labelNames {
+				var v interface{}
+				if value, ok := f.Labels[name]; ok {
+					v = value
+				}
+				columns[5+l].values = append(columns[5+l].values, v)
+			}
+		}
+	}
+	return exportTable{columns: columns, rows: rows}
+}
+
+// floatValue returns values[i], or nil if it is missing or NaN.
+func floatValue(values []float64, i int) interface{} {
+	if i >= len(values) || math.IsNaN(values[i]) {
+		return nil
+	}
+	return values[i]
+}
+
+func frameTable(frame *data.Frame) (exportTable, error) {
+	rows, err := frame.RowLen()
+	if err != nil {
+		return exportTable{}, err
+	}
+	columns := make([]exportColumn, len(frame.Fields))
+	for i, field := range frame.Fields {
+		col := exportColumn{name: field.Name, values: make([]interface{}, field.Len())}
+		if len(field.Labels) > 0 {
+			col.name += "{" + field.Labels.String() + "}"
+		}
+		switch t := field.Type(); {
+		case t.Time():
+			col.kind = kindTime
+		case t.Numeric():
+			col.kind = kindFloat
+		case t == data.FieldTypeBool || t == data.FieldTypeNullableBool:
+			col.kind = kindBool
+		default:
+			col.kind = kindString
+		}
+		for row := range col.values {
+			v, ok := field.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			switch col.kind {
+			case kindTime:
+				col.values[row] = v.(time.Time).UTC()
+			case kindFloat:
+				if f, err := field.FloatAt(row); err == nil && !math.IsNaN(f) {
+					col.values[row] = f
+				}
+			case kindBool:
+				col.values[row] = v
+			default:
+				if s, ok := v.(string); ok {
+					col.values[row] = s
+				} else {
+					col.values[row] = fmt.Sprint(v)
+				}
+			}
+		}
+		columns[i] = col
+	}
+	return exportTable{columns: columns, rows: rows}, nil
+}
+
+func writeTable(w io.Writer, format ExportFormat, t exportTable) error {
+	switch format {
+	case ExportCSV:
+		return writeCSV(w, t)
+	case ExportNDJSON:
+		return writeNDJSON(w, t)
+	case ExportArrow:
+		return writeArrow(w, t)
+	case ExportParquet:
+		return writeParquet(w, t)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// formatValue formats a value for text formats.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return v.(string)
+	}
+}
+
+func writeCSV(w io.Writer, t exportTable) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		record[i] = col.name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for row := 0; row < t.rows; row++ {
+		for i, col := range t.columns {
+			record[i] = formatValue(col.values[row])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeNDJSON(w io.Writer, t exportTable) error {
+	enc := json.NewEncoder(w)
+	for row := 0; row < t.rows; row++ {
+		// Build the object by hand to keep the column order.
+		obj := make([]byte, 0, 128)
+		obj = append(obj, '{')
+		for i, col := range t.columns {
+			if i > 0 {
+				obj = append(obj, ',')
+			}
+			name, _ := json.Marshal(col.name)
+			obj = append(obj, name...)
+			obj = append(obj, ':')
+			var v interface{}
+			if col.values[row] != nil {
+				v = col.values[row]
+				if col.kind == kindTime {
+					v = formatValue(v)
+				}
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			obj = append(obj, value...)
+		}
+		obj = append(obj, '}')
+		if err := enc.Encode(json.RawMessage(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record converts the table to an Arrow record. The caller must release it.
+func (t exportTable) record() arrow.Record {
+	mem := memory.NewGoAllocator()
+	fields := make([]arrow.Field, len(t.columns))
+	arrays := make([]arrow.Array, len(t.columns))
+	for i, col := range t.columns {
+		var b array.Builder
+		switch col.kind {
+		case kindTime:
+			tb := array.NewTimestampBuilder(mem, arrow.FixedWidthTypes.Timestamp_ms.(*arrow.TimestampType))
+			for _, v := range col.values {
+				if v == nil {
+					tb.AppendNull()
+				} else {
+					tb.Append(arrow.Timestamp(v.(time.Time).UnixMilli()))
+				}
+			}
+			b = tb
+		case kindFloat:
+			fb := array.NewFloat64Builder(mem)
+			for _, v := range col.values {
+				if v == nil {
+					fb.AppendNull()
+				} else {
+					fb.Append(v.(float64))
+				}
+			}
+			b = fb
+		case kindBool:
+			bb := array.NewBooleanBuilder(mem)
+			for _, v := range col.values {
+				if v == nil {
+					bb.AppendNull()
+				} else {
+					bb.Append(v.(bool))
+				}
+			}
+			b = bb
+		default:
+			sb := array.NewStringBuilder(mem)
+			for _, v := range col.values {
+				if v == nil {
+					sb.AppendNull()
+				} else {
+					sb.Append(v.(string))
+				}
+			}
+			b = sb
+		}
+		arrays[i] = b.NewArray()
+		b.Release()
+		fields[i] = arrow.Field{Name: col.name, Type: arrays[i].DataType(), Nullable: true}
+	}
+	rec := array.NewRecord(arrow.NewSchema(fields, nil), arrays, int64(t.rows))
+	for _, a := range arrays {
+		a.Release()
+	}
+	return rec
+}
+
+// offsetWriter tracks the number of bytes written, so that it can report its
+// position to writers that need an io.WriteSeeker. It cannot otherwise seek.
+// It also hides any Close method of w from writers that close their sink.
+type offsetWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *offsetWriter) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return 0, errors.New("seek not supported")
+	}
+	return o.offset, nil
+}
+
+func writeArrow(w io.Writer, t exportTable) error {
+	rec := t.record()
+	defer rec.Release()
+	fw, err := ipc.NewFileWriter(&offsetWriter{w: w}, ipc.WithSchema(rec.Schema()))
+	if err != nil {
+		return err
+	}
+	if err := fw.Write(rec); err != nil {
+		//nolint:errcheck // The write error is more relevant.
+		fw.Close()
+		return err
+	}
+	return fw.Close()
+}
+
+func writeParquet(w io.Writer, t exportTable) error {
+	rec := t.record()
+	defer rec.Release()
+	fw, err := pqarrow.NewFileWriter(rec.Schema(), &offsetWriter{w: w}, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	if err != nil {
+		return err
+	}
+	if err := fw.Write(rec); err != nil {
+		//nolint:errcheck // The write error is more relevant.
+		fw.Close()
+		return err
+	}
+	return fw.Close()
+}
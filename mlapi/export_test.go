@@ -0,0 +1,123 @@
+package mlapi
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testForecasts = []Forecast{
+	{
+		Labels:     data.Labels{"instance": "a"},
+		Timestamps: []time.Time{time.Unix(0, 0), time.Unix(60, 0)},
+		Actual:     []float64{1, math.NaN()},
+		Predicted:  []float64{1.5, 2.5},
+		Lower:      []float64{1, 2},
+		Upper:      []float64{2, 3},
+	},
+	{
+		Labels:     data.Labels{"job": "node"},
+		Timestamps: []time.Time{time.Date(1970, 1, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600))},
+		Predicted:  []float64{7},
+	},
+}
+
+func TestExportForecastsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportForecasts(&buf, ExportCSV, testForecasts))
+	assert.Equal(t, `time,actual,predicted,lower,upper,label_instance,label_job
+1970-01-01T00:00:00Z,1,1.5,1,2,a,
+1970-01-01T00:01:00Z,,2.5,2,3,a,
+1970-01-01T00:00:00Z,,7,,,,node
+`, buf.String())
+}
+
+func TestExportForecastsNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportForecasts(&buf, ExportNDJSON, testForecasts))
+	assert.Equal(t, `{"time":"1970-01-01T00:00:00Z","actual":1,"predicted":1.5,"lower":1,"upper":2,"label_instance":"a","label_job":null}
+{"time":"1970-01-01T00:01:00Z","actual":null,"predicted":2.5,"lower":2,"upper":3,"label_instance":"a","label_job":null}
+{"time":"1970-01-01T00:00:00Z","actual":null,"predicted":7,"lower":null,"upper":null,"label_instance":null,"label_job":"node"}
+`, buf.String())
+}
+
+func assertForecastRecord(t *testing.T, rec arrow.Record) {
+	t.Helper()
+	require.EqualValues(t, 3, rec.NumRows())
+	require.EqualValues(t, 7, rec.NumCols())
+	assert.Equal(t, ExportTimeColumn, rec.ColumnName(0))
+	assert.Equal(t, "label_job", rec.ColumnName(6))
+
+	times := rec.Column(0).(*array.Timestamp)
+	assert.Equal(t, arrow.FixedWidthTypes.Timestamp_ms, times.DataType())
+	assert.EqualValues(t, 60000, times.Value(1))
+	assert.EqualValues(t, 0, times.Value(2))
+
+	actual := rec.Column(1).(*array.Float64)
+	assert.Equal(t, 1.0, actual.Value(0))
+	assert.True(t, actual.IsNull(1))
+
+	jobs := rec.Column(6).(*array.String)
+	assert.True(t, jobs.IsNull(0))
+	assert.Equal(t, "node", jobs.Value(2))
+}
+
+func TestExportForecastsArrow(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportForecasts(&buf, ExportArrow, testForecasts))
+
+	r, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer r.Close()
+	require.Equal(t, 1, r.NumRecords())
+	rec, err := r.Record(0)
+	require.NoError(t, err)
+	assertForecastRecord(t, rec)
+}
+
+func TestExportForecastsParquet(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportForecasts(&buf, ExportParquet, testForecasts))
+
+	tbl, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(buf.Bytes()), nil, pqarrow.ArrowReadProperties{}, memory.NewGoAllocator())
+	require.NoError(t, err)
+	defer tbl.Release()
+	tr := array.NewTableReader(tbl, -1)
+	defer tr.Release()
+	require.True(t, tr.Next())
+	assertForecastRecord(t, tr.Record())
+}
+
+func TestExportFrame(t *testing.T) {
+	one := 1.0
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(60, 0)}),
+		data.NewField("value", data.Labels{"instance": "a"}, []*float64{&one, nil}),
+		data.NewField("ok", nil, []bool{true, false}),
+		data.NewField("note", nil, []string{"x", "y,z"}),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportFrame(&buf, ExportCSV, frame))
+	assert.Equal(t, `time,value{instance=a},ok,note
+1970-01-01T00:00:00Z,1,true,x
+1970-01-01T00:01:00Z,,false,"y,z"
+`, buf.String())
+
+	require.EqualError(t, ExportFrame(&buf, "xlsx", frame), `unknown export format "xlsx"`)
+
+	// Fields of different lengths are rejected.
+	frame.Fields[2] = data.NewField("ok", nil, []bool{true})
+	require.Error(t, ExportFrame(&buf, ExportCSV, frame))
+}
@@ -0,0 +1,228 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// MLMetricsDatasourceUID is the UID of the datasource serving the
+	// forecasts of persisted jobs in Grafana Cloud.
+	MLMetricsDatasourceUID = "grafanacloud-ml-metrics"
+
+	// forecastLabel is the label distinguishing the predicted value and its
+	// bounds in the ML metrics datasource.
+	forecastLabel = "ml_forecast"
+	nameLabel     = "__name__"
+)
+
+// Forecast is the forecast of a single series. The value slices are aligned
+// with Timestamps; missing points are NaN.
+type Forecast struct {
+	// Labels are the labels of the forecast series.
+	Labels     data.Labels
+	Timestamps []time.Time
+	// Actual is the observed value, where the response includes it.
+	Actual []float64
+	// Predicted is the predicted value.
+	Predicted []float64
+	// Lower and Upper are the bounds of the prediction interval.
+	Lower []float64
+	Upper []float64
+}
+
+// QueryJobForecast queries the forecast of a persisted job through the ML
+// metrics datasource, between start and end at the given step. It returns one
+// Forecast per series of the job, ordered by labels, including the actual
+// values the job was trained on.
+func (c *Client) QueryJobForecast(ctx context.Context, jobID string, start, end time.Time, step time.Duration) ([]Forecast, error) {
+	job, err := c.Job(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := c.queryDatasource(ctx, datasourceQuery{
+		datasourceUID:  MLMetricsDatasourceUID,
+		datasourceType: DatasourceTypePrometheus,
+		model: map[string]interface{}{
+			"refId": defaultRefID,
+			"expr":  job.Metric + ":predicted or " + job.Metric + ":actual",
+		},
+		from:     start,
+		to:       end,
+		interval: step,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying forecast of job %s: %w", jobID, err)
+	}
+	return DecodeForecastFrames(frames)
+}
+
+// DecodeForecasts decodes the forecasts in every query response of resp, such
+// as one returned by ForecastJob, keyed by refID. Queries that failed are left
+// out, and their errors joined in the returned error.
+func DecodeForecasts(resp backend.QueryDataResponse) (map[string][]Forecast, error) {
+	refIDs := make([]string, 0, len(resp.Responses))
+	for refID := range resp.Responses {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	forecasts := map[string][]Forecast{}
+	var errs []error
+	for _, refID := range refIDs {
+		r := resp.Responses[refID]
+		if r.Error != nil {
+			errs = append(errs, fmt.Errorf("query %s: %w", refID, r.Error))
+			continue
+		}
+		decoded, err := DecodeForecastFrames(r.Frames)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("query %s: %w", refID, err))
+			continue
+		}
+		forecasts[refID] = decoded
+	}
+	return forecasts, errors.Join(errs...)
+}
+
+type forecastComponent int
+
+const (
+	componentActual forecastComponent = iota
+	componentPredicted
+	componentLower
+	componentUpper
+)
+
+// forecastComponentOf identifies the forecast component held by a value field,
+// from its ml_forecast label, the suffix of its metric name, or failing that
+// its field or frame name.
+func forecastComponentOf(frame *data.Frame, field *data.Field) (forecastComponent, bool) {
+	metric := field.Labels[nameLabel]
+	if i := strings.LastIndex(metric, ":"); i >= 0 {
+		metric = metric[i+1:]
+	}
+	names := []string{field.Labels[forecastLabel], metric, field.Name, frame.Name}
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "y", "actual":
+			return componentActual, true
+		case "yhat", "predicted":
+			return componentPredicted, true
+		case "yhat_lower", "lower":
+			return componentLower, true
+		case "yhat_upper", "upper":
+			return componentUpper, true
+		}
+	}
+	return 0, false
+}
+
+// forecastPoint holds the values of every component at one timestamp.
+type forecastPoint [componentUpper + 1]float64
+
+// DecodeForecastFrames decodes the forecasts in frames. Value fields are
+// identified by their ml_forecast label (y, yhat, yhat_lower or yhat_upper),
+// the suffix of their metric name (:actual or :predicted), or their field or
+// frame name, and fields of other kinds are ignored. Fields are grouped into
+// one Forecast per label set, leaving out the ml_forecast and __name__ labels,
+// ordered by labels. It returns an error if the fields of a frame have
+// different lengths.
+func DecodeForecastFrames(frames data.Frames) ([]Forecast, error) {
+	type series struct {
+		labels data.Labels
+		points map[time.Time]*forecastPoint
+	}
+	all := map[string]*series{}
+	for _, frame := range frames {
+		if _, err := frame.RowLen(); err != nil {
+			return nil, fmt.Errorf("frame %q: %w", frame.Name, err)
+		}
+		var times *data.Field
+		for _, field := range frame.Fields {
+			if field.Type().Time() {
+				times = field
+				break
+			}
+		}
+		if times == nil {
+			continue
+		}
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+			component, ok := forecastComponentOf(frame, field)
+			if !ok {
+				continue
+			}
+			labels := data.Labels{}
+			for k, v := range field.Labels {
+				if k != forecastLabel && k != nameLabel {
+					labels[k] = v
+				}
+			}
+			s, ok := all[labels.String()]
+			if !ok {
+				s = &series{labels: labels, points: map[time.Time]*forecastPoint{}}
+				all[labels.String()] = s
+			}
+			for i := 0; i < field.Len(); i++ {
+				t, ok := timeAt(times, i)
+				if !ok {
+					continue
+				}
+				p, ok := s.points[t]
+				if !ok {
+					p = &forecastPoint{math.NaN(), math.NaN(), math.NaN(), math.NaN()}
+					s.points[t] = p
+				}
+				if v, err := field.NullableFloatAt(i); err == nil && v != nil {
+					p[component] = *v
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	forecasts := make([]Forecast, len(keys))
+	for i, k := range keys {
+		s := all[k]
+		f := Forecast{Labels: s.labels}
+		for t := range s.points {
+			f.Timestamps = append(f.Timestamps, t)
+		}
+		sort.Slice(f.Timestamps, func(a, b int) bool { return f.Timestamps[a].Before(f.Timestamps[b]) })
+		for _, t := range f.Timestamps {
+			p := s.points[t]
+			f.Actual = append(f.Actual, p[componentActual])
+			f.Predicted = append(f.Predicted, p[componentPredicted])
+			f.Lower = append(f.Lower, p[componentLower])
+			f.Upper = append(f.Upper, p[componentUpper])
+		}
+		forecasts[i] = f
+	}
+	return forecasts, nil
+}
+
+// timeAt returns the time at index i of a time field, which may be nullable.
+func timeAt(field *data.Field, i int) (time.Time, bool) {
+	v, ok := field.ConcreteAt(i)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, ok := v.(time.Time)
+	return t.UTC(), ok
+}
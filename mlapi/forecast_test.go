@@ -0,0 +1,162 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func forecastFrame(component string, labels data.Labels, start int, values ...float64) *data.Frame {
+	l := data.Labels{nameLabel: "cpu:predicted", forecastLabel: component}
+	if component == "" {
+		l = data.Labels{nameLabel: "cpu:actual"}
+	}
+	for k, v := range labels {
+		l[k] = v
+	}
+	times := make([]time.Time, len(values))
+	for i := range values {
+		times[i] = time.Unix(int64((start+i)*60), 0)
+	}
+	return data.NewFrame("",
+		data.NewField("Time", nil, times),
+		data.NewField("Value", l, values),
+	)
+}
+
+func TestQueryJobForecast(t *testing.T) {
+	var query map[string]interface{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manage/api/v1/jobs/job-1":
+			_ = json.NewEncoder(w).Encode(responseWrapper[Job]{Data: Job{ID: "job-1", Metric: "cpu"}})
+		case dsQueryPath:
+			body := struct {
+				From    string                   `json:"from"`
+				To      string                   `json:"to"`
+				Queries []map[string]interface{} `json:"queries"`
+			}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "0", body.From)
+			assert.Equal(t, "180000", body.To)
+			query = body.Queries[0]
+
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+				forecastFrame("yhat", data.Labels{"instance": "b"}, 0, 1, 2, 3),
+				forecastFrame("yhat_lower", data.Labels{"instance": "b"}, 1, 1.5, 2.5),
+				forecastFrame("yhat_upper", data.Labels{"instance": "b"}, 0, 1.5, 2.5, 3.5),
+				forecastFrame("yhat", data.Labels{"instance": "a"}, 0, 10),
+				forecastFrame("", data.Labels{"instance": "b"}, 0, 1.1, 1.9),
+			}}
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	forecasts, err := c.QueryJobForecast(context.Background(), "job-1", time.Unix(0, 0), time.Unix(180, 0), time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cpu:predicted or cpu:actual", query["expr"])
+	assert.Equal(t, map[string]interface{}{"uid": MLMetricsDatasourceUID, "type": DatasourceTypePrometheus}, query["datasource"])
+	assert.EqualValues(t, 60000, query["intervalMs"])
+
+	require.Len(t, forecasts, 2)
+	assert.Equal(t, data.Labels{"instance": "a"}, forecasts[0].Labels)
+	assert.Equal(t, []float64{10}, forecasts[0].Predicted)
+	assert.True(t, math.IsNaN(forecasts[0].Lower[0]))
+
+	b := forecasts[1]
+	assert.Equal(t, data.Labels{"instance": "b"}, b.Labels)
+	assert.Equal(t, []time.Time{time.Unix(0, 0).UTC(), time.Unix(60, 0).UTC(), time.Unix(120, 0).UTC()}, b.Timestamps)
+	assert.Equal(t, []float64{1, 2, 3}, b.Predicted)
+	assert.Equal(t, []float64{1.1, 1.9}, b.Actual[:2])
+	assert.True(t, math.IsNaN(b.Actual[2]))
+	assert.True(t, math.IsNaN(b.Lower[0]))
+	assert.Equal(t, []float64{1.5, 2.5}, b.Lower[1:])
+	assert.Equal(t, []float64{1.5, 2.5, 3.5}, b.Upper)
+}
+
+func TestQueryJobForecastError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manage/api/v1/jobs/job-1":
+			_ = json.NewEncoder(w).Encode(responseWrapper[Job]{Data: Job{ID: "job-1", Metric: "cpu"}})
+		case dsQueryPath:
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.ErrDataResponse(backend.StatusBadRequest, "bad query")
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(resp)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	_, err = c.QueryJobForecast(context.Background(), "job-1", time.Unix(0, 0), time.Unix(180, 0), time.Minute)
+	require.ErrorContains(t, err, "querying forecast of job job-1: query A: bad query")
+}
+
+func TestDecodeForecasts(t *testing.T) {
+	ts := []time.Time{time.Unix(0, 0), time.Unix(60, 0)}
+	two := 2.0
+	resp := backend.NewQueryDataResponse()
+	resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+		data.NewFrame("",
+			data.NewField("ds", nil, ts),
+			data.NewField("y", nil, []*float64{nil, &two}),
+			data.NewField("yhat", nil, []float64{1, 2}),
+			data.NewField("yhat_lower", nil, []float64{0, 1}),
+			data.NewField("yhat_upper", nil, []float64{2, 3}),
+			data.NewField("trend", nil, []float64{5, 5}),
+		),
+	}}
+	resp.Responses["B"] = backend.ErrDataResponse(backend.StatusBadRequest, "too many series")
+
+	forecasts, err := DecodeForecasts(*resp)
+	require.EqualError(t, err, "query B: too many series")
+	require.Len(t, forecasts, 1)
+	require.Len(t, forecasts["A"], 1)
+
+	f := forecasts["A"][0]
+	assert.Equal(t, data.Labels{}, f.Labels)
+	assert.Equal(t, []time.Time{time.Unix(0, 0).UTC(), time.Unix(60, 0).UTC()}, f.Timestamps)
+	assert.True(t, math.IsNaN(f.Actual[0]))
+	assert.Equal(t, 2.0, f.Actual[1])
+	assert.Equal(t, []float64{1, 2}, f.Predicted)
+	assert.Equal(t, []float64{0, 1}, f.Lower)
+	assert.Equal(t, []float64{2, 3}, f.Upper)
+}
+
+func TestDecodeForecastFramesLengthMismatch(t *testing.T) {
+	frames := data.Frames{data.NewFrame("forecast",
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0)}),
+		data.NewField("yhat", nil, []float64{1, 2}),
+	)}
+	_, err := DecodeForecastFrames(frames)
+	require.ErrorContains(t, err, `frame "forecast": `)
+
+	resp := backend.NewQueryDataResponse()
+	resp.Responses["A"] = backend.DataResponse{Frames: frames}
+	forecasts, err := DecodeForecasts(*resp)
+	require.ErrorContains(t, err, `query A: frame "forecast": `)
+	assert.Empty(t, forecasts)
+
+	_, err = actualSeries(frames)
+	require.ErrorContains(t, err, `frame "forecast": `)
+}
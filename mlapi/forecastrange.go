@@ -0,0 +1,187 @@
+package mlapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// DefaultForecastMaxPoints is the number of points per series requested by
+// each chunk of ForecastJobRange when ForecastRangeOptions.MaxPoints is not
+// set. The forecast API does not document a limit, so this is a conservative
+// guess borrowed from Prometheus's limit of 11,000 points per series; raise
+// MaxPoints if the API accepts more.
+const DefaultForecastMaxPoints = 11000
+
+// ForecastRangeOptions configure ForecastJobRange.
+type ForecastRangeOptions struct {
+	// MaxPoints is the maximum number of points per series in each request.
+	// Defaults to DefaultForecastMaxPoints.
+	MaxPoints int
+	// Concurrency is the number of chunks requested in parallel. Defaults to
+	// one, requesting chunks in order.
+	Concurrency int
+}
+
+// ForecastJobRange runs an ephemeral forecast like ForecastJob, splitting a
+// range with more than opts.MaxPoints points into chunks of at most that many
+// points. Consecutive chunks share their boundary point. The frames of every
+// chunk are merged into one frame per series, in time order and without
+// duplicate points.
+//
+// If any chunk fails, or a frame to merge has fields of different lengths,
+// ForecastJobRange returns an error. Errors of individual queries within a
+// chunk are kept in the merged response.
+func (c *Client) ForecastJobRange(ctx context.Context, spec ForecastRequest, opts ForecastRangeOptions) (backend.QueryDataResponse, error) {
+	chunks, err := forecastChunks(spec.ForecastParams, opts.MaxPoints)
+	if err != nil {
+		return backend.QueryDataResponse{}, err
+	}
+	if len(chunks) == 1 {
+		return c.ForecastJob(ctx, spec)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	results, err := runBulk(ctx, "", "forecast", chunks, BulkOptions{Concurrency: concurrency, StopOnError: true},
+		func(ctx context.Context, params ForecastParams) (backend.QueryDataResponse, error) {
+			chunk := spec
+			chunk.ForecastParams = params
+			return c.ForecastJob(ctx, chunk)
+		}, nil, nil)
+	if err != nil {
+		return backend.QueryDataResponse{}, fmt.Errorf("forecasting chunks: %w", err)
+	}
+
+	merged := backend.QueryDataResponse{Responses: backend.Responses{}}
+	for i, res := range results {
+		for refID, resp := range res.Item.Responses {
+			existing, ok := merged.Responses[refID]
+			switch {
+			case !ok:
+				merged.Responses[refID] = resp
+			case existing.Error != nil:
+			case resp.Error != nil:
+				merged.Responses[refID] = resp
+			default:
+				frames, err := mergeFrames(existing.Frames, resp.Frames)
+				if err != nil {
+					return backend.QueryDataResponse{}, fmt.Errorf("merging chunk %d of query %s: %w", i, refID, err)
+				}
+				existing.Frames = frames
+				merged.Responses[refID] = existing
+			}
+		}
+	}
+	return merged, nil
+}
+
+// forecastChunks splits the range of params into chunks of at most maxPoints
+// points.
+func forecastChunks(params ForecastParams, maxPoints int) ([]ForecastParams, error) {
+	if maxPoints <= 0 {
+		maxPoints = DefaultForecastMaxPoints
+	}
+	if maxPoints < 2 {
+		return nil, fmt.Errorf("max points must be at least 2, got %d", maxPoints)
+	}
+	if params.Interval == 0 {
+		return nil, fmt.Errorf("forecast interval must be set")
+	}
+	if params.End.Before(params.Start) {
+		return nil, fmt.Errorf("forecast end %s is before start %s", params.End, params.Start)
+	}
+
+	interval := time.Duration(params.Interval) * time.Second
+	span := time.Duration(maxPoints-1) * interval
+	var chunks []ForecastParams
+	for start := params.Start; ; start = start.Add(span) {
+		end := start.Add(span)
+		if !end.Before(params.End) {
+			return append(chunks, ForecastParams{Start: start, End: params.End, Interval: params.Interval}), nil
+		}
+		chunks = append(chunks, ForecastParams{Start: start, End: end, Interval: params.Interval})
+	}
+}
+
+// frameKey identifies the series of a frame across chunks by its name and
+// the names, labels and types of its fields.
+func frameKey(frame *data.Frame) string {
+	var b strings.Builder
+	b.WriteString(frame.Name)
+	for _, field := range frame.Fields {
+		fmt.Fprintf(&b, "|%s{%s}%s", field.Name, field.Labels, field.Type())
+	}
+	return b.String()
+}
+
+// mergeFrames appends the rows of each frame in next to the frame for the same
+// series in frames, skipping rows at or before the last time already present.
+// Frames for new series are added as they are.
+func mergeFrames(frames, next data.Frames) (data.Frames, error) {
+	index := make(map[string]*data.Frame, len(frames))
+	for _, frame := range frames {
+		index[frameKey(frame)] = frame
+	}
+	for _, frame := range next {
+		existing, ok := index[frameKey(frame)]
+		if !ok {
+			frames = append(frames, frame)
+			index[frameKey(frame)] = frame
+			continue
+		}
+		if err := appendNewRows(existing, frame); err != nil {
+			return nil, fmt.Errorf("frame %q: %w", frame.Name, err)
+		}
+	}
+	return frames, nil
+}
+
+// appendNewRows appends the rows of src to dst that are after the last time in
+// dst. Frames without a time field are appended in full. It returns an error
+// if the fields of either frame have different lengths.
+func appendNewRows(dst, src *data.Frame) error {
+	if _, err := dst.RowLen(); err != nil {
+		return err
+	}
+	rows, err := src.RowLen()
+	if err != nil {
+		return err
+	}
+	timeIndex := -1
+	for i, field := range dst.Fields {
+		if field.Type().Time() {
+			timeIndex = i
+			break
+		}
+	}
+	var last time.Time
+	hasLast := false
+	if timeIndex >= 0 {
+		for i := 0; i < dst.Fields[timeIndex].Len(); i++ {
+			if t, ok := timeAt(dst.Fields[timeIndex], i); ok && (!hasLast || t.After(last)) {
+				last, hasLast = t, true
+			}
+		}
+	}
+
+	for row := 0; row < rows; row++ {
+		if hasLast {
+			if t, ok := timeAt(src.Fields[timeIndex], row); ok && !t.After(last) {
+				continue
+			}
+		}
+		values := make([]interface{}, len(src.Fields))
+		for i, field := range src.Fields {
+			values[i] = field.At(row)
+		}
+		dst.AppendRow(values...)
+	}
+	return nil
+}
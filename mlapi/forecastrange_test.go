@@ -0,0 +1,110 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecastChunks(t *testing.T) {
+	start := time.Unix(0, 0).UTC()
+	params := ForecastParams{Start: start, End: start.Add(10 * time.Minute), Interval: 60}
+
+	chunks, err := forecastChunks(params, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []ForecastParams{
+		{Start: start, End: start.Add(4 * time.Minute), Interval: 60},
+		{Start: start.Add(4 * time.Minute), End: start.Add(8 * time.Minute), Interval: 60},
+		{Start: start.Add(8 * time.Minute), End: start.Add(10 * time.Minute), Interval: 60},
+	}, chunks)
+
+	chunks, err = forecastChunks(params, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []ForecastParams{params}, chunks)
+
+	_, err = forecastChunks(ForecastParams{Start: start, End: start.Add(time.Hour)}, 5)
+	require.EqualError(t, err, "forecast interval must be set")
+}
+
+func TestForecastJobRange(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []ForecastParams
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/predict/api/v1/forecast" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		spec := ForecastRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&spec))
+		mu.Lock()
+		requests = append(requests, spec.ForecastParams)
+		mu.Unlock()
+
+		params := spec.ForecastParams
+		var (
+			times  []time.Time
+			values []float64
+		)
+		for ts := params.Start; !ts.After(params.End); ts = ts.Add(time.Duration(params.Interval) * time.Second) {
+			times = append(times, ts)
+			values = append(values, float64(ts.Unix()/60))
+		}
+		resp := backend.NewQueryDataResponse()
+		resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+			data.NewFrame("forecast",
+				data.NewField("time", nil, times),
+				data.NewField("yhat", data.Labels{"instance": "a"}, values),
+			),
+		}}
+		_ = json.NewEncoder(w).Encode(responseWrapper[*backend.QueryDataResponse]{Data: resp})
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	start := time.Unix(0, 0).UTC()
+	spec := ForecastRequest{
+		Job:            prometheusJob(t, "cpu", "cpu", "prom", "up"),
+		ForecastParams: ForecastParams{Start: start, End: start.Add(10 * time.Minute), Interval: 60},
+	}
+	resp, err := c.ForecastJobRange(context.Background(), spec, ForecastRangeOptions{MaxPoints: 5, Concurrency: 2})
+	require.NoError(t, err)
+	assert.Len(t, requests, 3)
+
+	forecasts, err := DecodeForecasts(resp)
+	require.NoError(t, err)
+	require.Len(t, forecasts["A"], 1)
+	f := forecasts["A"][0]
+	assert.Len(t, f.Timestamps, 11)
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, f.Predicted)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+	rows, err := resp.Responses["A"].Frames[0].RowLen()
+	require.NoError(t, err)
+	assert.Equal(t, 11, rows)
+}
+
+func TestMergeFramesLengthMismatch(t *testing.T) {
+	frame := func(times []time.Time, values []float64) *data.Frame {
+		return data.NewFrame("forecast",
+			data.NewField("time", nil, times),
+			data.NewField("yhat", nil, values),
+		)
+	}
+	first := data.Frames{frame([]time.Time{time.Unix(0, 0)}, []float64{1})}
+	next := data.Frames{frame([]time.Time{time.Unix(60, 0)}, []float64{2, 3})}
+
+	_, err := mergeFrames(first, next)
+	require.ErrorContains(t, err, `frame "forecast": `)
+}
@@ -1,9 +1,7 @@
 package mlapi
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"time"
 )
 
@@ -58,57 +56,25 @@ type CustomPeriod struct {
 
 // NewHoliday creates a new holiday.
 func (c *Client) NewHoliday(ctx context.Context, holiday Holiday) (Holiday, error) {
-	data, err := json.Marshal(holiday)
-	if err != nil {
-		return Holiday{}, err
-	}
-	result := responseWrapper[Holiday]{}
-	err = c.request(ctx, "POST", "/manage/api/v1/holidays", nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Holiday{}, err
-	}
-	return result.Data, err
+	return c.HolidayResource().Create(ctx, holiday)
 }
 
 // Holidays fetches all existing holidays.
 func (c *Client) Holidays(ctx context.Context) ([]Holiday, error) {
-	result := responseWrapper[[]Holiday]{}
-	err := c.request(ctx, "GET", "/manage/api/v1/holidays", nil, nil, &result)
-	if err != nil {
-		return []Holiday{}, err
-	}
-	return result.Data, nil
+	return c.HolidayResource().List(ctx)
 }
 
 // Holiday fetches an existing holiday.
 func (c *Client) Holiday(ctx context.Context, id string) (Holiday, error) {
-	result := responseWrapper[Holiday]{}
-	err := c.request(ctx, "GET", "/manage/api/v1/holidays/"+id, nil, nil, &result)
-	if err != nil {
-		return Holiday{}, err
-	}
-	return result.Data, err
+	return c.HolidayResource().Get(ctx, id)
 }
 
 // UpdateHoliday updates an existing holiday.
 func (c *Client) UpdateHoliday(ctx context.Context, holiday Holiday) (Holiday, error) {
-	id := holiday.ID
-	// Clear the ID before sending otherwise validation fails.
-	holiday.ID = ""
-	data, err := json.Marshal(holiday)
-	if err != nil {
-		return Holiday{}, err
-	}
-
-	result := responseWrapper[Holiday]{}
-	err = c.request(ctx, "POST", "/manage/api/v1/holidays/"+id, nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Holiday{}, err
-	}
-	return result.Data, err
+	return c.HolidayResource().Update(ctx, holiday)
 }
 
 // DeleteHoliday deletes an existing holiday.
 func (c *Client) DeleteHoliday(ctx context.Context, id string) error {
-	return c.request(ctx, "DELETE", "/manage/api/v1/holidays/"+id, nil, nil, nil)
+	return c.HolidayResource().Delete(ctx, id)
 }
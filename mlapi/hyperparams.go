@@ -0,0 +1,173 @@
+package mlapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// HyperParams are typed hyperparameters for a single forecasting algorithm.
+//
+// Use Job.SetHyperParams to store them on a job and Job.TypedHyperParams to
+// read them back from a job returned by the API.
+type HyperParams interface {
+	// Algorithm returns the name of the algorithm the hyperparameters apply
+	// to.
+	Algorithm() string
+	// Validate checks the hyperparameters are within their allowed ranges.
+	Validate() error
+}
+
+// ProphetHyperParams are the hyperparameters of the Prophet algorithm. See
+// https://facebook.github.io/prophet/docs/diagnostics.html#hyperparameter-tuning
+// for a description of each. Zero values are omitted, in which case the server
+// default is used; DefaultProphetHyperParams returns those defaults.
+type ProphetHyperParams struct {
+	// ChangepointPriorScale controls how flexible the trend is. Larger values
+	// allow the trend to change more. Must not be negative.
+	ChangepointPriorScale float64 `json:"changepoint_prior_scale,omitempty"`
+	// SeasonalityPriorScale controls how flexible the seasonality is. Must
+	// not be negative.
+	SeasonalityPriorScale float64 `json:"seasonality_prior_scale,omitempty"`
+	// HolidaysPriorScale controls how flexible the holiday effects are. Must
+	// not be negative.
+	HolidaysPriorScale float64 `json:"holidays_prior_scale,omitempty"`
+	// SeasonalityMode is either "additive" or "multiplicative".
+	SeasonalityMode string `json:"seasonality_mode,omitempty"`
+	// Growth is the trend model, either "linear" or "flat".
+	Growth string `json:"growth,omitempty"`
+	// IntervalWidth is the width of the prediction interval, between 0 and 1
+	// exclusive.
+	IntervalWidth float64 `json:"interval_width,omitempty"`
+}
+
+// DefaultProphetHyperParams returns the hyperparameters the server uses for
+// Prophet jobs when none are specified.
+func DefaultProphetHyperParams() ProphetHyperParams {
+	return ProphetHyperParams{
+		ChangepointPriorScale: 0.05,
+		SeasonalityPriorScale: 10,
+		HolidaysPriorScale:    10,
+		SeasonalityMode:       "additive",
+		Growth:                "linear",
+		IntervalWidth:         0.95,
+	}
+}
+
+// Algorithm implements HyperParams.
+func (p ProphetHyperParams) Algorithm() string {
+	return AlgorithmProphet
+}
+
+// Validate implements HyperParams.
+func (p ProphetHyperParams) Validate() error {
+	var errs ValidationErrors
+	if p.ChangepointPriorScale < 0 {
+		errs.add("changepoint_prior_scale", "must not be negative")
+	}
+	if p.SeasonalityPriorScale < 0 {
+		errs.add("seasonality_prior_scale", "must not be negative")
+	}
+	if p.HolidaysPriorScale < 0 {
+		errs.add("holidays_prior_scale", "must not be negative")
+	}
+	switch p.SeasonalityMode {
+	case "", "additive", "multiplicative":
+	default:
+		errs.add("seasonality_mode", "must be one of additive or multiplicative, got %q", p.SeasonalityMode)
+	}
+	switch p.Growth {
+	case "", "linear", "flat":
+	default:
+		errs.add("growth", "must be one of linear or flat, got %q", p.Growth)
+	}
+	if p.IntervalWidth < 0 || p.IntervalWidth >= 1 {
+		errs.add("interval_width", "must be between 0 and 1")
+	}
+	return errs.err()
+}
+
+// RawHyperParams holds the hyperparameters of an algorithm that has no typed
+// representation in this package, which is every algorithm but Prophet. They
+// are passed to the server unchanged, and jobs using them do not pass
+// Job.Validate.
+type RawHyperParams struct {
+	Name   string
+	Params map[string]interface{}
+}
+
+// Algorithm implements HyperParams.
+func (p RawHyperParams) Algorithm() string {
+	return p.Name
+}
+
+// Validate implements HyperParams. Raw hyperparameters are not validated.
+func (p RawHyperParams) Validate() error {
+	return nil
+}
+
+// SetHyperParams sets the Algorithm and HyperParams of the job from typed
+// hyperparameters.
+func (j *Job) SetHyperParams(params HyperParams) error {
+	if raw, ok := params.(RawHyperParams); ok {
+		j.Algorithm = raw.Name
+		j.HyperParams = raw.Params
+		return nil
+	}
+	m, err := structToMap(params)
+	if err != nil {
+		return err
+	}
+	j.Algorithm = params.Algorithm()
+	j.HyperParams = m
+	return nil
+}
+
+// TypedHyperParams parses the HyperParams of the job into the typed
+// hyperparameters for its Algorithm. Only AlgorithmProphet has a typed
+// representation; the hyperparameters of other algorithms are returned as
+// RawHyperParams.
+// An error is returned if the HyperParams of a Prophet job contain keys
+// Prophet does not recognise.
+func (j Job) TypedHyperParams() (HyperParams, error) {
+	switch j.Algorithm {
+	case AlgorithmProphet:
+		params := ProphetHyperParams{}
+		if err := mapToStruct(j.HyperParams, &params, true); err != nil {
+			return nil, fmt.Errorf("invalid hyperParams: %w", err)
+		}
+		return params, nil
+	default:
+		return RawHyperParams{Name: j.Algorithm, Params: j.HyperParams}, nil
+	}
+}
+
+// structToMap converts v to the untyped map representation used by the API.
+func structToMap(v any) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mapToStruct converts an untyped map returned by the API into v. If strict
+// is set, keys without a matching field in v are an error.
+func mapToStruct(m map[string]interface{}, v any, strict bool) error {
+	if len(m) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
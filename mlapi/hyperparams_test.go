@@ -0,0 +1,62 @@
+package mlapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProphetHyperParams(t *testing.T) {
+	job := Job{}
+	require.NoError(t, job.SetHyperParams(DefaultProphetHyperParams()))
+	assert.Equal(t, AlgorithmProphet, job.Algorithm)
+	assert.Equal(t, map[string]interface{}{
+		"changepoint_prior_scale": 0.05,
+		"seasonality_prior_scale": float64(10),
+		"holidays_prior_scale":    float64(10),
+		"seasonality_mode":        "additive",
+		"growth":                  "linear",
+		"interval_width":          0.95,
+	}, job.HyperParams)
+
+	// Hyperparameters returned by the API are decoded back into their typed form.
+	returned := Job{}
+	require.NoError(t, json.Unmarshal([]byte(`{"algorithm":"grafana_prophet_1_0_1","hyperParams":{"changepoint_prior_scale":0.05,"growth":"linear","holidays_prior_scale":10,"interval_width":0.95,"seasonality_mode":"additive","seasonality_prior_scale":10}}`), &returned))
+	params, err := returned.TypedHyperParams()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProphetHyperParams(), params)
+
+	returned.HyperParams["changepointPriorScale"] = 0.1
+	_, err = returned.TypedHyperParams()
+	require.ErrorContains(t, err, `unknown field "changepointPriorScale"`)
+
+	err = ProphetHyperParams{Growth: "logistic", IntervalWidth: 1.5}.Validate()
+	assert.Equal(t, ValidationErrors{
+		{Field: "growth", Message: `must be one of linear or flat, got "logistic"`},
+		{Field: "interval_width", Message: "must be between 0 and 1"},
+	}, err)
+
+	// Zero values fall back to the server default, so only negative values
+	// are rejected.
+	assert.NoError(t, ProphetHyperParams{}.Validate())
+	err = ProphetHyperParams{ChangepointPriorScale: -1}.Validate()
+	assert.Equal(t, ValidationErrors{{Field: "changepoint_prior_scale", Message: "must not be negative"}}, err)
+}
+
+func TestRawHyperParams(t *testing.T) {
+	job := Job{Algorithm: "some_future_algorithm", HyperParams: map[string]interface{}{"depth": float64(3)}}
+	params, err := job.TypedHyperParams()
+	require.NoError(t, err)
+	assert.Equal(t, RawHyperParams{Name: "some_future_algorithm", Params: job.HyperParams}, params)
+
+	other := Job{}
+	require.NoError(t, other.SetHyperParams(params))
+	assert.Equal(t, job, other)
+
+	// Algorithms without typed hyperparameters are not accepted by Validate.
+	job = prometheusJob(t, "cpu", "cpu", "prom", "up")
+	require.NoError(t, job.SetHyperParams(params))
+	assert.Equal(t, ValidationErrors{{Field: "algorithm", Message: `unknown algorithm "some_future_algorithm"`}}, job.Validate())
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"path"
+	"regexp"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -55,89 +57,157 @@ type Job struct {
 	// ManagedBy is used to identify who controls system forecasts. It is
 	// required when creating system forecasts and must not be set otherwise.
 	ManagedBy string `json:"managedBy,omitempty"`
+
+	// Paused is set when training and alert evaluation of the job are
+	// stopped. It is read only and is not sent when the job is created or
+	// updated; use PauseJob and ResumeJob to change it.
+	Paused bool `json:"paused,omitempty"`
 }
 
-// NewJob creates a machine learning job and schedules a training.
-func (c *Client) NewJob(ctx context.Context, job Job) (Job, error) {
-	return c.newJob(ctx, job, "/manage/api/v1/jobs")
+// AlgorithmProphet is the Prophet forecasting algorithm, which has typed
+// hyperparameters; see Job.TypedHyperParams.
+const AlgorithmProphet = "grafana_prophet_1_0_1"
+
+// knownAlgorithms are the algorithms accepted by Validate. Only algorithms with
+// typed, validated hyperparameters are listed.
+var knownAlgorithms = map[string]bool{
+	AlgorithmProphet: true,
 }
 
-// NewSystemJob creates a system machine learning job and schedules a training.
-func (c *Client) NewSystemJob(ctx context.Context, job Job) (Job, error) {
-	return c.newJob(ctx, job, "/manage/api/v1/system-jobs")
+const (
+	// minJobInterval is the smallest data resolution accepted by Validate.
+	minJobInterval = 10
+	// minTrainingFrequency is the smallest training frequency accepted by
+	// Validate.
+	minTrainingFrequency = 60 * 60
+)
+
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Validate checks a job for problems that would cause it to be rejected by
+// the API, without making any requests. It returns ValidationErrors listing
+// every invalid field, or nil if the job is valid.
+//
+// Validate only accepts algorithms whose hyperparameters it can check, which
+// is AlgorithmProphet. Jobs using other algorithms are reported as having an
+// unknown algorithm; leave Config.ValidateJobs unset to create them.
+//
+// Validate treats the job as a user job; use ValidateSystem for system jobs.
+func (j Job) Validate() error {
+	errs := j.validate()
+	if j.ManagedBy != "" {
+		errs.add("managedBy", "must only be set on system jobs")
+	}
+	return errs.err()
 }
 
-func (c *Client) newJob(ctx context.Context, job Job, path string) (Job, error) {
-	data, err := json.Marshal(job)
-	if err != nil {
-		return Job{}, err
+// ValidateSystem is like Validate but checks the job as a system job, which
+// must have ManagedBy set.
+func (j Job) ValidateSystem() error {
+	errs := j.validate()
+	if j.ManagedBy == "" {
+		errs.add("managedBy", "is required for system jobs")
 	}
+	return errs.err()
+}
 
-	result := responseWrapper[Job]{}
-	err = c.request(ctx, "POST", path, nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Job{}, err
+func (j Job) validate() ValidationErrors {
+	var errs ValidationErrors
+	if j.Name == "" {
+		errs.add("name", "is required")
 	}
-	return result.Data, nil
+	if j.Metric == "" {
+		errs.add("metric", "is required")
+	} else if !metricNameRegexp.MatchString(j.Metric) {
+		errs.add("metric", "%q is not a valid Prometheus metric name", j.Metric)
+	}
+	if j.DatasourceUID == "" && j.DatasourceID == 0 {
+		errs.add("datasourceUid", "one of datasourceUid or datasourceId is required")
+	}
+	if j.DatasourceType == "" {
+		errs.add("datasourceType", "is required")
+	} else {
+		params, err := j.TypedQueryParams()
+		if err == nil {
+			err = params.Validate()
+		}
+		errs.merge("queryParams", err)
+	}
+
+	if j.Interval < minJobInterval {
+		errs.add("interval", "must be at least %d seconds", minJobInterval)
+	}
+	if j.TrainingWindow == 0 {
+		errs.add("trainingWindow", "is required")
+	} else if j.Interval != 0 && j.TrainingWindow%j.Interval != 0 {
+		errs.add("trainingWindow", "must be a multiple of interval (%d)", j.Interval)
+	} else if j.TrainingWindow < 2*j.Interval {
+		errs.add("trainingWindow", "must cover at least two intervals")
+	}
+	if j.TrainingFrequency < minTrainingFrequency {
+		errs.add("trainingFrequency", "must be at least %d seconds", minTrainingFrequency)
+	} else if j.TrainingWindow != 0 && j.TrainingFrequency > j.TrainingWindow {
+		errs.add("trainingFrequency", "must not be greater than trainingWindow (%d)", j.TrainingWindow)
+	}
+
+	if j.Algorithm == "" {
+		errs.add("algorithm", "is required")
+	} else if !knownAlgorithms[j.Algorithm] {
+		errs.add("algorithm", "unknown algorithm %q", j.Algorithm)
+	} else {
+		params, err := j.TypedHyperParams()
+		if err == nil {
+			err = params.Validate()
+		}
+		errs.merge("hyperParams", err)
+	}
+	return errs
+}
+
+// NewJob creates a machine learning job and schedules a training.
+//
+// If Config.ValidateJobs is set the job is validated before it is sent.
+func (c *Client) NewJob(ctx context.Context, job Job) (Job, error) {
+	return c.JobResource().Create(ctx, job)
+}
+
+// NewSystemJob creates a system machine learning job and schedules a training.
+func (c *Client) NewSystemJob(ctx context.Context, job Job) (Job, error) {
+	return c.SystemJobResource().Create(ctx, job)
 }
 
 // Jobs fetches all existing machine learning jobs.
 func (c *Client) Jobs(ctx context.Context) ([]Job, error) {
-	result := responseWrapper[[]Job]{}
-	err := c.request(ctx, "GET", "/manage/api/v1/jobs", nil, nil, &result)
-	if err != nil {
-		return []Job{}, err
-	}
-	return result.Data, nil
+	return c.JobResource().List(ctx)
 }
 
 // Job fetches an existing machine learning job.
 func (c *Client) Job(ctx context.Context, id string) (Job, error) {
-	result := responseWrapper[Job]{}
-	err := c.request(ctx, "GET", "/manage/api/v1/jobs/"+id, nil, nil, &result)
-	if err != nil {
-		return Job{}, err
-	}
-	return result.Data, nil
+	return c.JobResource().Get(ctx, id)
 }
 
 // UpdateJob updates a machine learning job. A new training will be scheduled as part of updating.
+//
+// If Config.ValidateJobs is set the job is validated before it is sent.
 func (c *Client) UpdateJob(ctx context.Context, job Job) (Job, error) {
-	return c.updateJob(ctx, job, "/manage/api/v1/jobs/")
+	return c.JobResource().Update(ctx, job)
 }
 
 // UpdateSystemJob updates a system machine learning job and schedules a new
 // training. It can also be used to change a user job into a system job if
 // necessary.
 func (c *Client) UpdateSystemJob(ctx context.Context, job Job) (Job, error) {
-	return c.updateJob(ctx, job, "/manage/api/v1/system-jobs/")
-}
-
-func (c *Client) updateJob(ctx context.Context, job Job, path string) (Job, error) {
-	id := job.ID
-	// Clear the ID before sending otherwise validation fails.
-	job.ID = ""
-	data, err := json.Marshal(job)
-	if err != nil {
-		return Job{}, err
-	}
-
-	result := responseWrapper[Job]{}
-	err = c.request(ctx, "POST", path+id, nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return Job{}, err
-	}
-	return result.Data, nil
+	return c.SystemJobResource().Update(ctx, job)
 }
 
 // DeleteJob deletes a machine learning job.
 func (c *Client) DeleteJob(ctx context.Context, id string) error {
-	return c.request(ctx, "DELETE", "/manage/api/v1/jobs/"+id, nil, nil, nil)
+	return c.JobResource().Delete(ctx, id)
 }
 
 // DeleteJob deletes a system machine learning job.
 func (c *Client) DeleteSystemJob(ctx context.Context, id string) error {
-	return c.request(ctx, "DELETE", "/manage/api/v1/system-jobs/"+id, nil, nil, nil)
+	return c.SystemJobResource().Delete(ctx, id)
 }
 
 // LinkHolidaysToJob links a job to a set of holidays.
@@ -153,7 +223,7 @@ func (c *Client) LinkHolidaysToJob(ctx context.Context, jobID string, holidayIDs
 	}
 
 	result := responseWrapper[Job]{}
-	err = c.request(ctx, "PUT", "/manage/api/v1/jobs/"+jobID+"/holidays", nil, bytes.NewReader(data), &result)
+	err = c.request(ctx, "PUT", path.Join(jobsPath, jobID, "holidays"), nil, bytes.NewReader(data), &result)
 	if err != nil {
 		return Job{}, err
 	}
@@ -186,12 +256,29 @@ type ForecastRequest struct {
 // This is a convenience API to avoid having to create a job, wait for it to train,
 // and then query the forecast. It is designed for exploratory usage rather than
 // to be called regularly; if you want to regularly query a forecast, create a job
-// and query it using the `grafanacloud-ml-metrics` datasource.
+// and query it using the `grafanacloud-ml-metrics` datasource, for example with
+// QueryJobForecast.
 // Jobs specified in the ForecastRequest must have a single series, or this
-// will return an error.
+// will return an error. Use ForecastJobPerSeries for jobs with more series.
 // This function may be slow the first time it is called, but the result will be
-// cached for 24 hours after that.
+// cached for 24 hours after that. Set Config.ForecastCache to also cache it on
+// the client.
 func (c *Client) ForecastJob(ctx context.Context, spec ForecastRequest) (backend.QueryDataResponse, error) {
+	cache := c.config.ForecastCache
+	var key string
+	if cache != nil {
+		var err error
+		key, err = forecastCacheKey(c.baseURL.String(), c.config.BearerToken, spec)
+		if err != nil {
+			return backend.QueryDataResponse{}, err
+		}
+		if cacheBypassed(ctx) {
+			cache.miss()
+		} else if resp, ok := cache.get(key); ok {
+			return resp, nil
+		}
+	}
+
 	data, err := json.Marshal(spec)
 	if err != nil {
 		return backend.QueryDataResponse{}, err
@@ -202,5 +289,8 @@ func (c *Client) ForecastJob(ctx context.Context, spec ForecastRequest) (backend
 	if err != nil {
 		return backend.QueryDataResponse{}, err
 	}
+	if cache != nil {
+		cache.put(key, result.Data)
+	}
 	return result.Data, nil
 }
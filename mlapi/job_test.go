@@ -268,3 +268,61 @@ func TestLinkHolidaysToJob(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, returnedJob.Holidays, []string{"6d2d261c-7efc-4106-832c-751ba4bda77e"})
 }
+
+func TestJobValidate(t *testing.T) {
+	valid := Job{
+		Name:              "Test Job",
+		Metric:            "test_job",
+		DatasourceUID:     "abcd1234",
+		DatasourceType:    "prometheus",
+		QueryParams:       map[string]interface{}{"expr": "sum(up)", "refId": "A"},
+		Interval:          300,
+		TrainingWindow:    7776000,
+		TrainingFrequency: 86400,
+		Algorithm:         AlgorithmProphet,
+	}
+	require.NoError(t, valid.Validate())
+
+	system := valid
+	system.ManagedBy = "test"
+	require.NoError(t, system.ValidateSystem())
+	require.EqualError(t, system.Validate(), "validation failed: managedBy: must only be set on system jobs")
+	require.EqualError(t, valid.ValidateSystem(), "validation failed: managedBy: is required for system jobs")
+
+	invalid := Job{
+		Metric:            "test-job",
+		DatasourceID:      10,
+		Interval:          300,
+		TrainingWindow:    1000,
+		TrainingFrequency: 86400,
+		Algorithm:         "prophet",
+	}
+	err := invalid.Validate()
+	var errs ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, ValidationErrors{
+		{Field: "name", Message: "is required"},
+		{Field: "metric", Message: `"test-job" is not a valid Prometheus metric name`},
+		{Field: "datasourceType", Message: "is required"},
+		{Field: "trainingWindow", Message: "must be a multiple of interval (300)"},
+		{Field: "trainingFrequency", Message: "must not be greater than trainingWindow (1000)"},
+		{Field: "algorithm", Message: `unknown algorithm "prophet"`},
+	}, errs)
+}
+
+func TestNewJobValidation(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid job should not be sent")
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{ValidateJobs: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.NewJob(ctx, Job{})
+	var errs ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	_, err = c.UpdateJob(ctx, Job{ID: "8b154ff8-3d64-4b79-8b26-02b4baeb44e4"})
+	require.ErrorAs(t, err, &errs)
+}
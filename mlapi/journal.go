@@ -0,0 +1,194 @@
+package mlapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JournalEntry records the outcome of a single item of a bulk operation.
+type JournalEntry struct {
+	Time time.Time `json:"time"`
+	// Resource is the collection path of the resource the operation ran on,
+	// as returned by Resource.Path, so that a journal can be shared by bulk
+	// operations on different resources.
+	Resource string `json:"resource"`
+	// Op is the bulk operation: create, update or delete.
+	Op string `json:"op"`
+	// Key identifies the item within the bulk input. See BulkOptions.Key.
+	Key string `json:"key"`
+	// ID is the ID of the created, updated or deleted resource.
+	ID string `json:"id,omitempty"`
+	// Error is the error message if the item failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Succeeded reports whether the entry records a successful item.
+func (e JournalEntry) Succeeded() bool {
+	return e.Error == ""
+}
+
+// Journal is an append-only JSON lines file recording the outcome of each item
+// of bulk operations, so that an interrupted run can be resumed. Set
+// BulkOptions.Journal to use it: items the journal records as succeeded are
+// not attempted again, and failed items are retried.
+//
+// A Journal is safe for concurrent use.
+type Journal struct {
+	mu        sync.Mutex
+	file      *os.File
+	enc       *json.Encoder
+	succeeded map[journalKey]JournalEntry
+}
+
+// OpenJournal opens the journal at path, creating it if it does not exist.
+func OpenJournal(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	entries, err := readJournal(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// Terminate a line truncated by an interrupted run so that new entries
+	// start on a line of their own.
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if _, err := f.Write([]byte("\n")); err != nil {
+			//nolint:errcheck // The write error is more relevant.
+			f.Close()
+			return nil, err
+		}
+	}
+	j := &Journal{
+		file:      f,
+		enc:       json.NewEncoder(f),
+		succeeded: map[journalKey]JournalEntry{},
+	}
+	for _, e := range entries {
+		j.track(e)
+	}
+	return j, nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// journalKey identifies an item across the runs of a bulk operation.
+type journalKey struct {
+	resource, op, key string
+}
+
+func (e JournalEntry) journalKey() journalKey {
+	return journalKey{resource: e.Resource, op: e.Op, key: e.Key}
+}
+
+func (j *Journal) track(e JournalEntry) {
+	if e.Succeeded() {
+		j.succeeded[e.journalKey()] = e
+	} else {
+		delete(j.succeeded, e.journalKey())
+	}
+}
+
+// completed returns the entry of a previously succeeded item, if any.
+func (j *Journal) completed(resource, op, key string) (JournalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e, ok := j.succeeded[journalKey{resource: resource, op: op, key: key}]
+	return e, ok
+}
+
+func (j *Journal) record(e JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.enc.Encode(e); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	j.track(e)
+	return nil
+}
+
+// ReadJournal reads all entries of the journal at path, in the order they were
+// written.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck // The file is only read.
+	defer f.Close()
+	return readJournal(f)
+}
+
+func readJournal(r io.Reader) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	// Entries record server error messages, which can be long, so lines are
+	// read whole rather than with a bufio.Scanner and its line length limit.
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		var e JournalEntry
+		// A run killed mid-write can leave a truncated line. Skip it; the
+		// item it describes is simply retried.
+		if len(bytes.TrimSpace(line)) > 0 && json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+		}
+		if err != nil {
+			return entries, nil
+		}
+	}
+}
+
+// JournalSummary is the latest state of every item in a journal.
+type JournalSummary struct {
+	// Succeeded is the number of items whose latest entry succeeded.
+	Succeeded int
+	// Failed lists the latest entry of every item that has not succeeded,
+	// ordered by resource, operation and key.
+	Failed []JournalEntry
+}
+
+// SummarizeJournal summarizes journal entries, keeping only the latest entry
+// for each item.
+func SummarizeJournal(entries []JournalEntry) JournalSummary {
+	latest := map[journalKey]JournalEntry{}
+	for _, e := range entries {
+		latest[e.journalKey()] = e
+	}
+	summary := JournalSummary{}
+	for _, e := range latest {
+		if e.Succeeded() {
+			summary.Succeeded++
+		} else {
+			summary.Failed = append(summary.Failed, e)
+		}
+	}
+	sort.Slice(summary.Failed, func(a, b int) bool {
+		fa, fb := summary.Failed[a], summary.Failed[b]
+		if fa.Resource != fb.Resource {
+			return fa.Resource < fb.Resource
+		}
+		if fa.Op != fb.Op {
+			return fa.Op < fb.Op
+		}
+		return fa.Key < fb.Key
+	})
+	return summary
+}
@@ -0,0 +1,154 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkCreateJournal(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts = map[string]int{}
+		failing  = map[string]bool{"b": true}
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := Holiday{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&h))
+		mu.Lock()
+		attempts[h.Name]++
+		fail := failing[h.Name]
+		mu.Unlock()
+		if fail {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		h.ID = "id-" + h.Name
+		_ = json.NewEncoder(w).Encode(responseWrapper[Holiday]{Data: h})
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	holidays := []Holiday{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	run := func() []BulkResult[Holiday] {
+		journal, err := OpenJournal(path)
+		require.NoError(t, err)
+		defer journal.Close()
+		results, _ := c.HolidayResource().BulkCreate(ctx, holidays, BulkOptions{
+			Journal: journal,
+			Key:     func(i int) string { return holidays[i].Name },
+		})
+		return results
+	}
+
+	results := run()
+	assert.Error(t, results[1].Err)
+
+	summary := SummarizeJournal(mustReadJournal(t, path))
+	assert.Equal(t, 2, summary.Succeeded)
+	require.Len(t, summary.Failed, 1)
+	assert.Equal(t, "b", summary.Failed[0].Key)
+
+	// The rerun only retries the failed item.
+	failing["b"] = false
+	results = run()
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, attempts)
+	for i, res := range results {
+		require.NoError(t, res.Err)
+		assert.Equal(t, "id-"+holidays[i].Name, res.Item.ID)
+		assert.Equal(t, i != 1, res.Resumed)
+	}
+
+	summary = SummarizeJournal(mustReadJournal(t, path))
+	assert.Equal(t, 3, summary.Succeeded)
+	assert.Empty(t, summary.Failed)
+}
+
+func TestOpenJournalTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"op":"delete","key":"0","id":"1"}`+"\n"+`{"op":"delete","key":"1","i`), 0o600))
+
+	journal, err := OpenJournal(path)
+	require.NoError(t, err)
+	_, ok := journal.completed("", "delete", "0")
+	assert.True(t, ok)
+	_, ok = journal.completed("", "delete", "1")
+	assert.False(t, ok)
+
+	require.NoError(t, journal.record(JournalEntry{Op: "delete", Key: "1", ID: "2"}))
+	require.NoError(t, journal.Close())
+
+	entries := mustReadJournal(t, path)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "2", entries[1].ID)
+}
+
+func TestJournalSharedByResources(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		deleted = append(deleted, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	require.NoError(t, err)
+	defer journal.Close()
+
+	// Both deletes use the default keys "0" and "1", but are for different
+	// resources, so the second is not resumed from the first.
+	_, err = c.JobResource().BulkDelete(ctx, []string{"1", "2"}, BulkOptions{Journal: journal})
+	require.NoError(t, err)
+	results, err := c.HolidayResource().BulkDelete(ctx, []string{"1", "2"}, BulkOptions{Journal: journal})
+	require.NoError(t, err)
+	for _, res := range results {
+		assert.False(t, res.Resumed)
+	}
+	assert.ElementsMatch(t, []string{
+		"/manage/api/v1/jobs/1", "/manage/api/v1/jobs/2",
+		"/manage/api/v1/holidays/1", "/manage/api/v1/holidays/2",
+	}, deleted)
+}
+
+func TestReadJournalLongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenJournal(path)
+	require.NoError(t, err)
+	long := strings.Repeat("x", 100_000)
+	require.NoError(t, journal.record(JournalEntry{Op: "create", Key: "0", Error: long}))
+	require.NoError(t, journal.record(JournalEntry{Op: "create", Key: "1", ID: "1"}))
+	require.NoError(t, journal.Close())
+
+	entries := mustReadJournal(t, path)
+	require.Len(t, entries, 2)
+	assert.Equal(t, long, entries[0].Error)
+	assert.Equal(t, "1", entries[1].ID)
+}
+
+func mustReadJournal(t *testing.T, path string) []JournalEntry {
+	t.Helper()
+	entries, err := ReadJournal(path)
+	require.NoError(t, err)
+	return entries
+}
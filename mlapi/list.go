@@ -0,0 +1,173 @@
+package mlapi
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ListOptions filter and paginate the results of list requests.
+//
+// Options are sent to the server as query parameters. Results are also
+// filtered on the client, so options work the same against servers that
+// ignore them. Options that do not apply to a resource type, such as Metric
+// for holidays, are ignored.
+type ListOptions struct {
+	// NamePrefix selects resources whose name starts with the prefix.
+	NamePrefix string
+	// Metric selects resources with this metric name.
+	Metric string
+	// DatasourceUID selects resources querying the datasource with this UID.
+	DatasourceUID string
+	// Algorithm selects resources using the algorithm with this name.
+	Algorithm string
+	// Labels selects jobs whose CustomLabels match the selector.
+	Labels LabelSelector
+	// ManagedBy selects system jobs managed by this owner.
+	ManagedBy string
+
+	// Limit is the maximum number of resources per page. Zero uses the server
+	// default.
+	Limit int
+	// Cursor is the position to start listing from, as returned in
+	// Page.NextCursor. Empty starts from the beginning.
+	Cursor string
+}
+
+func (o ListOptions) values() url.Values {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("namePrefix", o.NamePrefix)
+	set("metric", o.Metric)
+	set("datasourceUid", o.DatasourceUID)
+	set("algorithm", o.Algorithm)
+	set("managedBy", o.ManagedBy)
+	set("cursor", o.Cursor)
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	keys := make([]string, 0, len(o.Labels))
+	for k := range o.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		q.Add("label", k+"="+o.Labels[k])
+	}
+	return q
+}
+
+// Page is a single page of list results.
+type Page[T any] struct {
+	Items []T
+	// NextCursor is the cursor for the next page, or empty if this is the last
+	// page.
+	NextCursor string
+}
+
+// WithFilter returns a copy of the Resource that applies filter to list
+// results, for servers that do not support filtering by ListOptions.
+func (r *Resource[T]) WithFilter(filter func(T, ListOptions) bool) *Resource[T] {
+	copied := *r
+	copied.filter = filter
+	return &copied
+}
+
+// ListPage fetches a single page of items matching opts.
+func (r *Resource[T]) ListPage(ctx context.Context, opts ListOptions) (Page[T], error) {
+	if err := r.validatePath(); err != nil {
+		return Page[T]{}, err
+	}
+	result := responseWrapper[[]T]{}
+	err := r.client.request(ctx, "GET", r.path, opts.values(), nil, &result)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	items := result.Data
+	if r.filter != nil {
+		items = items[:0:0]
+		for _, item := range result.Data {
+			if r.filter(item, opts) {
+				items = append(items, item)
+			}
+		}
+	}
+	return Page[T]{Items: items, NextCursor: result.NextCursor}, nil
+}
+
+// All iterates over every item matching opts, fetching further pages as
+// needed. Iteration stops after the first error, and when the server returns
+// a cursor that was already fetched.
+func (r *Resource[T]) All(ctx context.Context, opts ListOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		seen := map[string]bool{opts.Cursor: true}
+		for {
+			page, err := r.ListPage(ctx, opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			// A cursor that was already fetched would only repeat its pages,
+			// and loop forever if the server's cursors form a cycle.
+			if page.NextCursor == "" || seen[page.NextCursor] {
+				return
+			}
+			seen[page.NextCursor] = true
+			opts.Cursor = page.NextCursor
+		}
+	}
+}
+
+// ListJobs iterates over the machine learning jobs matching opts.
+func (c *Client) ListJobs(ctx context.Context, opts ListOptions) iter.Seq2[Job, error] {
+	return c.JobResource().All(ctx, opts)
+}
+
+// ListOutlierDetectors iterates over the outlier detectors matching opts.
+func (c *Client) ListOutlierDetectors(ctx context.Context, opts ListOptions) iter.Seq2[OutlierDetector, error] {
+	return c.OutlierDetectorResource().All(ctx, opts)
+}
+
+// ListHolidays iterates over the holidays matching opts. Only NamePrefix
+// applies to holidays.
+func (c *Client) ListHolidays(ctx context.Context, opts ListOptions) iter.Seq2[Holiday, error] {
+	return c.HolidayResource().All(ctx, opts)
+}
+
+func matchOption(want, got string) bool {
+	return want == "" || want == got
+}
+
+func jobMatches(j Job, opts ListOptions) bool {
+	return strings.HasPrefix(j.Name, opts.NamePrefix) &&
+		matchOption(opts.Metric, j.Metric) &&
+		matchOption(opts.DatasourceUID, j.DatasourceUID) &&
+		matchOption(opts.Algorithm, j.Algorithm) &&
+		matchOption(opts.ManagedBy, j.ManagedBy) &&
+		opts.Labels.Matches(j.CustomLabels)
+}
+
+func outlierDetectorMatches(o OutlierDetector, opts ListOptions) bool {
+	return strings.HasPrefix(o.Name, opts.NamePrefix) &&
+		matchOption(opts.Metric, o.Metric) &&
+		matchOption(opts.DatasourceUID, o.DatasourceUID) &&
+		matchOption(opts.Algorithm, o.Algorithm.Name)
+}
+
+func holidayMatches(h Holiday, opts ListOptions) bool {
+	return strings.HasPrefix(h.Name, opts.NamePrefix)
+}
@@ -0,0 +1,104 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListJobs(t *testing.T) {
+	pages := map[string]responseWrapper[[]Job]{
+		"": {Data: []Job{
+			{ID: "1", Name: "cpu-a", Metric: "cpu", CustomLabels: map[string]interface{}{"team": "a"}},
+			{ID: "2", Name: "mem-a", Metric: "mem", CustomLabels: map[string]interface{}{"team": "a"}},
+		}, NextCursor: "2"},
+		"2": {Data: []Job{
+			{ID: "3", Name: "cpu-b", Metric: "cpu", CustomLabels: map[string]interface{}{"team": "b"}},
+			{ID: "4", Name: "cpu-c", Metric: "cpu", CustomLabels: map[string]interface{}{"team": "a"}},
+		}},
+	}
+	var queries []url.Values
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/manage/api/v1/jobs" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		queries = append(queries, r.URL.Query())
+		// Paginate but ignore the filters, so the client has to apply them.
+		_ = json.NewEncoder(w).Encode(pages[r.URL.Query().Get("cursor")])
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var ids []string
+	for job, err := range c.ListJobs(ctx, ListOptions{NamePrefix: "cpu", Labels: LabelSelector{"team": "a"}, Limit: 2}) {
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+	assert.Equal(t, []string{"1", "4"}, ids)
+	assert.Equal(t, []url.Values{
+		{"namePrefix": {"cpu"}, "label": {"team=a"}, "limit": {"2"}},
+		{"namePrefix": {"cpu"}, "label": {"team=a"}, "limit": {"2"}, "cursor": {"2"}},
+	}, queries)
+
+	// Jobs follows pagination too.
+	jobs, err := c.Jobs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 4)
+}
+
+func TestListPage(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"status":"success","data":[{"id":"1","name":"Test OutlierDetector","metric":"test_outlier","algorithm":{"name":"dbscan"}},{"id":"2","name":"Other","metric":"test_outlier","algorithm":{"name":"mad"}}],"nextCursor":"abc"}`))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	page, err := c.OutlierDetectorResource().ListPage(context.Background(), ListOptions{Algorithm: "dbscan"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "1", page.Items[0].ID)
+	assert.Equal(t, "abc", page.NextCursor)
+}
+
+func TestListCursorCycle(t *testing.T) {
+	// The server's cursors go A, B, A, ... forever.
+	pages := map[string]responseWrapper[[]Holiday]{
+		"":  {Data: []Holiday{{ID: "1"}}, NextCursor: "A"},
+		"A": {Data: []Holiday{{ID: "2"}}, NextCursor: "B"},
+		"B": {Data: []Holiday{{ID: "3"}}, NextCursor: "A"},
+	}
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_ = json.NewEncoder(w).Encode(pages[r.URL.Query().Get("cursor")])
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	var ids []string
+	for holiday, err := range c.ListHolidays(context.Background(), ListOptions{}) {
+		require.NoError(t, err)
+		ids = append(ids, holiday.ID)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+	assert.Equal(t, 3, requests)
+}
@@ -0,0 +1,115 @@
+package mlapi
+
+import (
+	"math"
+)
+
+// ForecastMetrics measure the accuracy of a forecast against actual values.
+// Metrics that have no points to be computed from are NaN.
+type ForecastMetrics struct {
+	// Points is the number of points with both an actual and a predicted
+	// value.
+	Points int
+	// MAE is the mean absolute error.
+	MAE float64
+	// MAPE is the mean absolute percentage error, leaving out points whose
+	// actual value is zero.
+	MAPE float64
+	// SMAPE is the symmetric mean absolute percentage error, from 0 to 200.
+	SMAPE float64
+	// RMSE is the root mean squared error.
+	RMSE float64
+	// Coverage is the fraction of points with prediction bounds whose actual
+	// value is within the bounds.
+	Coverage float64
+}
+
+// metricsAccumulator sums the errors of forecast points, so that metrics can
+// be pooled across series and windows.
+type metricsAccumulator struct {
+	points        int
+	absErr, sqErr float64
+	pctErr        float64
+	pctPoints     int
+	symErr        float64
+	symPoints     int
+	covered       int
+	boundedPoints int
+}
+
+// add adds a point. Missing values are NaN; points without an actual or
+// predicted value are ignored.
+func (a *metricsAccumulator) add(actual, predicted, lower, upper float64) {
+	if math.IsNaN(actual) || math.IsNaN(predicted) {
+		return
+	}
+	diff := math.Abs(actual - predicted)
+	a.points++
+	a.absErr += diff
+	a.sqErr += diff * diff
+	if actual != 0 {
+		a.pctErr += diff / math.Abs(actual)
+		a.pctPoints++
+	}
+	if denom := math.Abs(actual) + math.Abs(predicted); denom != 0 {
+		a.symErr += 2 * diff / denom
+		a.symPoints++
+	} else {
+		// Both are zero, a perfect prediction.
+		a.symPoints++
+	}
+	if !math.IsNaN(lower) && !math.IsNaN(upper) {
+		a.boundedPoints++
+		if actual >= lower && actual <= upper {
+			a.covered++
+		}
+	}
+}
+
+func (a *metricsAccumulator) merge(b metricsAccumulator) {
+	a.points += b.points
+	a.absErr += b.absErr
+	a.sqErr += b.sqErr
+	a.pctErr += b.pctErr
+	a.pctPoints += b.pctPoints
+	a.symErr += b.symErr
+	a.symPoints += b.symPoints
+	a.covered += b.covered
+	a.boundedPoints += b.boundedPoints
+}
+
+func (a metricsAccumulator) metrics() ForecastMetrics {
+	ratio := func(sum float64, n int) float64 {
+		if n == 0 {
+			return math.NaN()
+		}
+		return sum / float64(n)
+	}
+	return ForecastMetrics{
+		Points:   a.points,
+		MAE:      ratio(a.absErr, a.points),
+		MAPE:     100 * ratio(a.pctErr, a.pctPoints),
+		SMAPE:    100 * ratio(a.symErr, a.symPoints),
+		RMSE:     math.Sqrt(ratio(a.sqErr, a.points)),
+		Coverage: ratio(float64(a.covered), a.boundedPoints),
+	}
+}
+
+// ComputeMetrics computes the accuracy of predicted values against actual
+// values at the same positions. lower and upper are the prediction bounds,
+// and may be nil. Missing values are NaN.
+func ComputeMetrics(actual, predicted, lower, upper []float64) ForecastMetrics {
+	var acc metricsAccumulator
+	for i := range actual {
+		acc.add(actual[i], at(predicted, i), at(lower, i), at(upper, i))
+	}
+	return acc.metrics()
+}
+
+// at returns values[i], or NaN if values is too short.
+func at(values []float64, i int) float64 {
+	if i >= len(values) {
+		return math.NaN()
+	}
+	return values[i]
+}
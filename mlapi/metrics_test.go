@@ -0,0 +1,34 @@
+package mlapi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeMetrics(t *testing.T) {
+	nan := math.NaN()
+	m := ComputeMetrics(
+		[]float64{10, 0, 20, nan, 5},
+		[]float64{12, 0, 15, 1, nan},
+		[]float64{9, -1, 16, 0, 0},
+		[]float64{13, 1, 19, 2, 10},
+	)
+	assert.Equal(t, 3, m.Points)
+	assert.InDelta(t, 7.0/3, m.MAE, 1e-9)
+	// Zero actual values are left out of MAPE.
+	assert.InDelta(t, 100*(0.2+0.25)/2, m.MAPE, 1e-9)
+	assert.InDelta(t, 100*(4.0/22+0+10.0/35)/3, m.SMAPE, 1e-9)
+	assert.InDelta(t, math.Sqrt(29.0/3), m.RMSE, 1e-9)
+	assert.InDelta(t, 2.0/3, m.Coverage, 1e-9)
+
+	m = ComputeMetrics([]float64{1, 2}, []float64{1, 2}, nil, nil)
+	assert.Equal(t, 2, m.Points)
+	assert.Zero(t, m.MAE)
+	assert.True(t, math.IsNaN(m.Coverage))
+
+	m = ComputeMetrics(nil, nil, nil, nil)
+	assert.Zero(t, m.Points)
+	assert.True(t, math.IsNaN(m.MAE))
+}
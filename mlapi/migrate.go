@@ -0,0 +1,284 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// MigrateOptions select the resources Migrate copies and how they are changed
+// for the destination stack.
+type MigrateOptions struct {
+	// Holidays, Jobs and OutlierDetectors select the resources of each kind to
+	// copy. A nil selector copies none of that kind.
+	Holidays         *ListOptions
+	Jobs             *ListOptions
+	OutlierDetectors *ListOptions
+	// SkipAlerts disables copying the alerts of copied jobs and outlier
+	// detectors.
+	SkipAlerts bool
+
+	// DatasourceUIDs maps datasource UIDs in the source stack to the UIDs of
+	// the equivalent datasources in the destination stack. Both the
+	// DatasourceUID of jobs and outlier detectors and the `datasource.uid`
+	// the Grafana query editor stores in their QueryParams are remapped. UIDs
+	// without a mapping are kept. Numeric datasource IDs differ between
+	// stacks, so they are always cleared in favour of the UID.
+	DatasourceUIDs map[string]string
+	// GrafanaURLs maps Grafana URLs in the source stack to the destination
+	// stack. URLs without a mapping are kept.
+	GrafanaURLs map[string]string
+
+	// Concurrency is the number of resources created in parallel. Defaults to
+	// DefaultBulkConcurrency.
+	Concurrency int
+}
+
+// MigrationFailure is a resource that could not be copied.
+type MigrationFailure struct {
+	// Kind is the kind of resource: holiday, job, outlier detector, job alert
+	// or outlier alert.
+	Kind string
+	// SourceID is the ID of the resource in the source stack.
+	SourceID string
+	// Name is the name or title of the resource.
+	Name string
+	Err  error
+}
+
+// MigrationReport maps the IDs of copied resources in the source stack to
+// their IDs in the destination stack, and lists the resources that failed.
+type MigrationReport struct {
+	Holidays         map[string]string
+	Jobs             map[string]string
+	OutlierDetectors map[string]string
+	// Alerts maps the IDs of both job and outlier alerts.
+	Alerts   map[string]string
+	Failures []MigrationFailure
+}
+
+func (r *MigrationReport) fail(kind, id, name string, err error) {
+	r.Failures = append(r.Failures, MigrationFailure{Kind: kind, SourceID: id, Name: name, Err: err})
+}
+
+// Err returns an error joining every failure, or nil if there were none.
+func (r MigrationReport) Err() error {
+	errs := make([]error, len(r.Failures))
+	for i, f := range r.Failures {
+		errs[i] = fmt.Errorf("%s %q (%s): %w", f.Kind, f.Name, f.SourceID, f.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// Migrate copies resources from the src client's stack to the dst client's
+// stack.
+//
+// Holidays are copied first so that the holiday IDs in Job.Holidays can be
+// remapped to the copies; a job linked to a holiday that was not copied fails.
+// Jobs and outlier detectors are then copied along with their alerts, and
+// paused in the destination if they are paused in the source. Migrate carries
+// on past failures, which are listed in the report; the returned error is only
+// set if listing the source resources fails.
+func Migrate(ctx context.Context, src, dst *Client, opts MigrateOptions) (MigrationReport, error) {
+	report := MigrationReport{
+		Holidays:         map[string]string{},
+		Jobs:             map[string]string{},
+		OutlierDetectors: map[string]string{},
+		Alerts:           map[string]string{},
+	}
+	bulk := BulkOptions{Concurrency: opts.Concurrency}
+
+	if opts.Holidays != nil {
+		holidays, err := collect(src.ListHolidays(ctx, *opts.Holidays))
+		if err != nil {
+			return report, fmt.Errorf("listing holidays: %w", err)
+		}
+		results, _ := runBulk(ctx, "", "create", holidays, bulk, func(ctx context.Context, h Holiday) (Holiday, error) {
+			h.ID = ""
+			// Links are recreated from Job.Holidays once the jobs exist.
+			h.Jobs = nil
+			return dst.NewHoliday(ctx, h)
+		}, nil, nil)
+		for i, res := range results {
+			if res.Err != nil {
+				report.fail("holiday", holidays[i].ID, holidays[i].Name, res.Err)
+				continue
+			}
+			report.Holidays[holidays[i].ID] = res.Item.ID
+		}
+	}
+
+	if opts.Jobs != nil {
+		jobs, err := collect(src.ListJobs(ctx, *opts.Jobs))
+		if err != nil {
+			return report, fmt.Errorf("listing jobs: %w", err)
+		}
+		results, _ := runBulk(ctx, "", "create", jobs, bulk, func(ctx context.Context, j Job) (Job, error) {
+			return migrateJob(ctx, dst, j, opts, report.Holidays)
+		}, nil, nil)
+		for i, res := range results {
+			if res.Err != nil {
+				report.fail("job", jobs[i].ID, jobs[i].Name, res.Err)
+			}
+			// A resource that was created but failed to pause is still
+			// mapped, and gets its alerts.
+			if res.Item.ID == "" {
+				continue
+			}
+			report.Jobs[jobs[i].ID] = res.Item.ID
+			if !opts.SkipAlerts {
+				migrateAlerts(ctx, "job alert", src.JobAlertResource(jobs[i].ID), dst.JobAlertResource(res.Item.ID), &report)
+			}
+		}
+	}
+
+	if opts.OutlierDetectors != nil {
+		outliers, err := collect(src.ListOutlierDetectors(ctx, *opts.OutlierDetectors))
+		if err != nil {
+			return report, fmt.Errorf("listing outlier detectors: %w", err)
+		}
+		results, _ := runBulk(ctx, "", "create", outliers, bulk, func(ctx context.Context, o OutlierDetector) (OutlierDetector, error) {
+			return migrateOutlierDetector(ctx, dst, o, opts)
+		}, nil, nil)
+		for i, res := range results {
+			if res.Err != nil {
+				report.fail("outlier detector", outliers[i].ID, outliers[i].Name, res.Err)
+			}
+			// A resource that was created but failed to pause is still
+			// mapped, and gets its alerts.
+			if res.Item.ID == "" {
+				continue
+			}
+			report.OutlierDetectors[outliers[i].ID] = res.Item.ID
+			if !opts.SkipAlerts {
+				migrateAlerts(ctx, "outlier alert", src.OutlierAlertResource(outliers[i].ID), dst.OutlierAlertResource(res.Item.ID), &report)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func migrateJob(ctx context.Context, dst *Client, job Job, opts MigrateOptions, holidayIDs map[string]string) (Job, error) {
+	paused := job.Paused
+	job.ID = ""
+	job.Paused = false
+	job.DatasourceUID, job.DatasourceID = remapDatasource(job.DatasourceUID, job.DatasourceID, opts.DatasourceUIDs)
+	job.QueryParams = remapQueryDatasource(job.QueryParams, opts.DatasourceUIDs)
+	job.GrafanaURL = remap(job.GrafanaURL, opts.GrafanaURLs)
+
+	holidays := make([]string, len(job.Holidays))
+	for i, id := range job.Holidays {
+		dstID, ok := holidayIDs[id]
+		if !ok {
+			return Job{}, fmt.Errorf("linked holiday %s was not migrated", id)
+		}
+		holidays[i] = dstID
+	}
+	job.Holidays = holidays
+
+	var (
+		created Job
+		err     error
+	)
+	if job.ManagedBy != "" {
+		created, err = dst.NewSystemJob(ctx, job)
+	} else {
+		created, err = dst.NewJob(ctx, job)
+	}
+	if err != nil || !paused {
+		return created, err
+	}
+	if _, err := dst.PauseJob(ctx, created.ID); err != nil {
+		return created, fmt.Errorf("created as %s but failed to pause: %w", created.ID, err)
+	}
+	return created, nil
+}
+
+func migrateOutlierDetector(ctx context.Context, dst *Client, outlier OutlierDetector, opts MigrateOptions) (OutlierDetector, error) {
+	paused := outlier.Paused
+	outlier.ID = ""
+	outlier.Paused = false
+	outlier.DatasourceUID, outlier.DatasourceID = remapDatasource(outlier.DatasourceUID, outlier.DatasourceID, opts.DatasourceUIDs)
+	outlier.QueryParams = remapQueryDatasource(outlier.QueryParams, opts.DatasourceUIDs)
+	outlier.GrafanaURL = remap(outlier.GrafanaURL, opts.GrafanaURLs)
+
+	created, err := dst.NewOutlierDetector(ctx, outlier)
+	if err != nil || !paused {
+		return created, err
+	}
+	if _, err := dst.PauseOutlierDetector(ctx, created.ID); err != nil {
+		return created, fmt.Errorf("created as %s but failed to pause: %w", created.ID, err)
+	}
+	return created, nil
+}
+
+func migrateAlerts(ctx context.Context, kind string, src, dst *Resource[Alert], report *MigrationReport) {
+	alerts, err := src.List(ctx)
+	if err != nil {
+		report.fail(kind, src.Path(), "", fmt.Errorf("listing alerts: %w", err))
+		return
+	}
+	for _, alert := range alerts {
+		srcID := alert.ID
+		alert.ID = ""
+		alert.SyncError = ""
+		created, err := dst.Create(ctx, alert)
+		if err != nil {
+			report.fail(kind, srcID, alert.Title, err)
+			continue
+		}
+		report.Alerts[srcID] = created.ID
+	}
+}
+
+func remapDatasource(uid string, id uint, uids map[string]string) (string, uint) {
+	if uid == "" {
+		// Without a UID there is nothing to remap to, so keep the ID.
+		return uid, id
+	}
+	return remap(uid, uids), 0
+}
+
+// remapQueryDatasource returns a copy of query params with the UID of their
+// `datasource` object remapped. Params without one are returned unchanged.
+func remapQueryDatasource(params map[string]interface{}, uids map[string]string) map[string]interface{} {
+	ds, ok := params["datasource"].(map[string]interface{})
+	if !ok {
+		return params
+	}
+	uid, ok := ds["uid"].(string)
+	if !ok {
+		return params
+	}
+	remappedDS := make(map[string]interface{}, len(ds))
+	for k, v := range ds {
+		remappedDS[k] = v
+	}
+	remappedDS["uid"] = remap(uid, uids)
+	remapped := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		remapped[k] = v
+	}
+	remapped["datasource"] = remappedDS
+	return remapped
+}
+
+func remap(value string, mapping map[string]string) string {
+	if mapped, ok := mapping[value]; ok {
+		return mapped
+	}
+	return value
+}
+
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	items := []T{}
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
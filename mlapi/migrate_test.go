@@ -0,0 +1,152 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStack serves the collections of a stack from memory. Collections are
+// keyed by their path below /manage/api/v1.
+type fakeStack struct {
+	mu          sync.Mutex
+	prefix      string
+	collections map[string][]map[string]interface{}
+	paused      []string
+	nextID      int
+}
+
+func newFakeStack(prefix string) *fakeStack {
+	return &fakeStack{prefix: prefix, collections: map[string][]map[string]interface{}{}}
+}
+
+func (f *fakeStack) add(collection string, item interface{}) {
+	data, _ := json.Marshal(item)
+	m := map[string]interface{}{}
+	_ = json.Unmarshal(data, &m)
+	f.collections[collection] = append(f.collections[collection], m)
+}
+
+func (f *fakeStack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/manage/api/v1/")
+	enc := json.NewEncoder(w)
+
+	switch {
+	case r.Method == "POST" && strings.HasSuffix(path, "/pause"):
+		f.paused = append(f.paused, strings.TrimSuffix(path, "/pause"))
+		_ = enc.Encode(responseWrapper[map[string]interface{}]{Data: map[string]interface{}{}})
+	case r.Method == "POST":
+		item := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if name, _ := item["name"].(string); name == "rejected" {
+			http.Error(w, "rejected", http.StatusBadRequest)
+			return
+		}
+		f.nextID++
+		item["id"] = fmt.Sprintf("%s%d", f.prefix, f.nextID)
+		f.collections[path] = append(f.collections[path], item)
+		_ = enc.Encode(responseWrapper[map[string]interface{}]{Data: item})
+	case r.Method == "GET":
+		items := f.collections[path]
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		_ = enc.Encode(responseWrapper[[]map[string]interface{}]{Data: items})
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	src := newFakeStack("src-")
+	src.add("holidays", Holiday{ID: "h1", Name: "christmas", Jobs: []string{"j1"}})
+	src.add("jobs", Job{
+		ID: "j1", Name: "cpu", Metric: "cpu", DatasourceUID: "prom-a", DatasourceID: 3,
+		GrafanaURL: "https://a.grafana.net", Holidays: []string{"h1"},
+		QueryParams: map[string]interface{}{
+			"expr":       "cpu",
+			"datasource": map[string]interface{}{"type": "prometheus", "uid": "prom-a"},
+		},
+	})
+	src.add("jobs", Job{ID: "j2", Name: "mem", Metric: "mem", DatasourceUID: "prom-b", Paused: true})
+	src.add("jobs", Job{ID: "j3", Name: "disk", Metric: "disk", Holidays: []string{"missing"}})
+	src.add("jobs/j1/alerts", Alert{ID: "a1", Title: "cpu high", SyncError: "stale"})
+	src.add("outliers", OutlierDetector{ID: "o1", Name: "rejected", Metric: "latency"})
+	src.add("outliers", OutlierDetector{
+		ID: "o2", Name: "errors", Metric: "errors", DatasourceUID: "prom-a",
+		QueryParams: map[string]interface{}{"datasource": map[string]interface{}{"uid": "prom-a"}},
+	})
+	srcServer := httptest.NewServer(src)
+	defer srcServer.Close()
+
+	dst := newFakeStack("dst-")
+	dstServer := httptest.NewServer(dst)
+	defer dstServer.Close()
+
+	srcClient, err := New(srcServer.URL, Config{})
+	require.NoError(t, err)
+	dstClient, err := New(dstServer.URL, Config{})
+	require.NoError(t, err)
+
+	report, err := Migrate(context.Background(), srcClient, dstClient, MigrateOptions{
+		Holidays:         &ListOptions{},
+		Jobs:             &ListOptions{},
+		OutlierDetectors: &ListOptions{},
+		DatasourceUIDs:   map[string]string{"prom-a": "prom-x"},
+		GrafanaURLs:      map[string]string{"https://a.grafana.net": "https://b.grafana.net"},
+		Concurrency:      1,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"h1": "dst-1"}, report.Holidays)
+	require.Len(t, report.Jobs, 2)
+	require.Len(t, report.OutlierDetectors, 1)
+	require.Len(t, report.Failures, 2)
+	assert.Equal(t, "j3", report.Failures[0].SourceID)
+	assert.ErrorContains(t, report.Failures[0].Err, "linked holiday missing was not migrated")
+	assert.Equal(t, "outlier detector", report.Failures[1].Kind)
+	assert.Equal(t, "o1", report.Failures[1].SourceID)
+	assert.Error(t, report.Err())
+
+	holiday := dst.collections["holidays"][0]
+	assert.Nil(t, holiday["jobs"], "links are recreated through the jobs")
+
+	cpu := dst.collections["jobs"][0]
+	if cpu["name"] != "cpu" {
+		cpu = dst.collections["jobs"][1]
+	}
+	assert.Equal(t, "prom-x", cpu["datasourceUid"])
+	assert.EqualValues(t, 0, cpu["datasourceId"])
+	assert.Equal(t, "https://b.grafana.net", cpu["grafanaUrl"])
+	assert.Equal(t, []interface{}{"dst-1"}, cpu["holidays"])
+	assert.Equal(t, map[string]interface{}{
+		"expr":       "cpu",
+		"datasource": map[string]interface{}{"type": "prometheus", "uid": "prom-x"},
+	}, cpu["queryParams"])
+
+	outlier := dst.collections["outliers"][0]
+	assert.Equal(t, "prom-x", outlier["datasourceUid"])
+	assert.Equal(t, map[string]interface{}{"datasource": map[string]interface{}{"uid": "prom-x"}}, outlier["queryParams"])
+
+	cpuID := report.Jobs["j1"]
+	alerts := dst.collections["jobs/"+cpuID+"/alerts"]
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "cpu high", alerts[0]["title"])
+	assert.Nil(t, alerts[0]["syncError"])
+	assert.Equal(t, map[string]string{"a1": alerts[0]["id"].(string)}, report.Alerts)
+
+	assert.Equal(t, []string{"jobs/" + report.Jobs["j2"]}, dst.paused)
+}
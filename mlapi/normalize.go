@@ -0,0 +1,133 @@
+package mlapi
+
+import (
+	"sort"
+)
+
+// Normalize returns a copy of the job in canonical form, so that a job as
+// written by a user and the same job as returned by the API compare equal:
+//
+//   - numbers in HyperParams, QueryParams and CustomLabels are float64, as
+//     they are when decoded from JSON;
+//   - empty maps and slices are nil;
+//   - Holidays are sorted;
+//   - HyperParams of Prophet jobs have the server defaults filled in.
+func (j Job) Normalize() Job {
+	j.CustomLabels = normalizeMap(j.CustomLabels)
+	j.QueryParams = normalizeMap(j.QueryParams)
+	j.HyperParams = normalizeMap(j.HyperParams)
+	if j.Algorithm == AlgorithmProphet {
+		defaults, _ := structToMap(DefaultProphetHyperParams())
+		for k, v := range defaults {
+			if _, ok := j.HyperParams[k]; !ok {
+				if j.HyperParams == nil {
+					j.HyperParams = map[string]interface{}{}
+				}
+				j.HyperParams[k] = v
+			}
+		}
+	}
+	j.Holidays = sortedStrings(j.Holidays)
+	return j
+}
+
+// SemanticEqual reports whether two jobs are the same once normalized,
+// ignoring fields populated by the server. Holidays are compared as given; use
+// Client.ResolveHolidays to compare a job naming its holidays with one
+// returned by the API.
+func (j Job) SemanticEqual(other Job) bool {
+	changes, err := DiffJob(j.Normalize(), other.Normalize())
+	return err == nil && len(changes) == 0
+}
+
+// Normalize returns a copy of the outlier detector in canonical form. See
+// Job.Normalize.
+func (o OutlierDetector) Normalize() OutlierDetector {
+	o.QueryParams = normalizeMap(o.QueryParams)
+	return o
+}
+
+// SemanticEqual reports whether two outlier detectors are the same once
+// normalized, ignoring fields populated by the server.
+func (o OutlierDetector) SemanticEqual(other OutlierDetector) bool {
+	changes, err := DiffOutlierDetector(o.Normalize(), other.Normalize())
+	return err == nil && len(changes) == 0
+}
+
+// Normalize returns a copy of the holiday in canonical form: Jobs are sorted,
+// empty slices are nil and custom period times are in UTC.
+func (h Holiday) Normalize() Holiday {
+	h.Jobs = sortedStrings(h.Jobs)
+	if len(h.CustomPeriods) == 0 {
+		h.CustomPeriods = nil
+	} else {
+		periods := make(CustomPeriods, len(h.CustomPeriods))
+		for i, p := range h.CustomPeriods {
+			p.StartTime = p.StartTime.UTC()
+			p.EndTime = p.EndTime.UTC()
+			periods[i] = p
+		}
+		h.CustomPeriods = periods
+	}
+	return h
+}
+
+// SemanticEqual reports whether two holidays are the same once normalized,
+// ignoring fields populated by the server.
+func (h Holiday) SemanticEqual(other Holiday) bool {
+	changes, err := DiffHoliday(h.Normalize(), other.Normalize())
+	return err == nil && len(changes) == 0
+}
+
+// Normalize returns a copy of the alert in canonical form: an empty
+// NoDataState is OK, matching the server default, and empty maps are nil.
+// Durations such as For and Window compare by value, so `5m` and `300s` are
+// already equal.
+func (a Alert) Normalize() Alert {
+	if a.NoDataState == "" {
+		a.NoDataState = NoDataStateOK
+	}
+	if len(a.Labels) == 0 {
+		a.Labels = nil
+	}
+	if len(a.Annotations) == 0 {
+		a.Annotations = nil
+	}
+	return a
+}
+
+// SemanticEqual reports whether two alerts are the same once normalized,
+// ignoring fields populated by the server.
+func (a Alert) SemanticEqual(other Alert) bool {
+	changes, err := DiffAlert(a.Normalize(), other.Normalize())
+	return err == nil && len(changes) == 0
+}
+
+// normalizeMap returns a copy of m with values converted to the types produced
+// by decoding JSON, or nil if m is empty.
+func normalizeMap(m map[string]interface{}) map[string]interface{} {
+	if len(m) == 0 {
+		return nil
+	}
+	normalized, err := structToMap(m)
+	if err != nil {
+		// Values that cannot be represented in JSON cannot be sent to the API
+		// either, so leave them for the request to report. The map is still
+		// copied, as Job.Normalize adds to it.
+		copied := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			copied[k] = v
+		}
+		return copied
+	}
+	return normalized
+}
+
+func sortedStrings(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	sorted := append([]string{}, s...)
+	sort.Strings(sorted)
+	return sorted
+}
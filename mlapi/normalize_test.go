@@ -0,0 +1,87 @@
+package mlapi
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobSemanticEqual(t *testing.T) {
+	desired := Job{
+		Name:         "Test Job",
+		Algorithm:    AlgorithmProphet,
+		HyperParams:  map[string]interface{}{"holidays_prior_scale": 20},
+		QueryParams:  map[string]interface{}{"expr": "sum(up)"},
+		CustomLabels: map[string]interface{}{},
+		Holidays:     []string{"b", "a"},
+	}
+
+	// The job as returned by the API: numbers are float64, defaults are
+	// filled in and holidays are reordered.
+	actual := Job{}
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"8b154ff8-3d64-4b79-8b26-02b4baeb44e4","name":"Test Job","algorithm":"grafana_prophet_1_0_1","hyperParams":{"changepoint_prior_scale":0.05,"growth":"linear","holidays_prior_scale":20,"interval_width":0.95,"seasonality_mode":"additive","seasonality_prior_scale":10},"queryParams":{"expr":"sum(up)"},"holidays":["a","b"]}`), &actual))
+
+	assert.True(t, desired.SemanticEqual(actual))
+
+	changes, err := DiffJob(actual.Normalize(), desired.Normalize())
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	desired.HyperParams["growth"] = "flat"
+	assert.False(t, desired.SemanticEqual(actual))
+
+	// Normalizing never changes the job's own maps, even when they cannot be
+	// encoded.
+	unencodable := Job{Algorithm: AlgorithmProphet, HyperParams: map[string]interface{}{"growth": math.NaN()}}
+	normalized := unencodable.Normalize()
+	assert.Len(t, unencodable.HyperParams, 1)
+	assert.Contains(t, normalized.HyperParams, "interval_width")
+}
+
+func TestHolidaySemanticEqual(t *testing.T) {
+	loc := time.FixedZone("UTC+1", 60*60)
+	a := Holiday{
+		Name: "Test Holiday",
+		Jobs: []string{"2", "1"},
+		CustomPeriods: CustomPeriods{{
+			StartTime: time.Date(2022, 1, 1, 1, 0, 0, 0, loc),
+			EndTime:   time.Date(2022, 1, 2, 1, 0, 0, 0, loc),
+		}},
+	}
+	b := Holiday{
+		ID:   "6d2d261c-7efc-4106-832c-751ba4bda77e",
+		Name: "Test Holiday",
+		Jobs: []string{"1", "2"},
+		CustomPeriods: CustomPeriods{{
+			StartTime: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	assert.True(t, a.SemanticEqual(b))
+	assert.Equal(t, []string{"2", "1"}, a.Jobs, "Normalize must not modify the original")
+}
+
+func TestAlertSemanticEqual(t *testing.T) {
+	desired := Alert{
+		Title:       "test job alert",
+		For:         model.Duration(5 * time.Minute),
+		Annotations: map[string]string{},
+	}
+	actual := Alert{}
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"5218f38f-569b-448f-b81d-578173412195","title":"test job alert","for":"300s","window":"0s","noDataCondition":"OK","syncError":"failed"}`), &actual))
+	assert.True(t, desired.SemanticEqual(actual))
+
+	desired.NoDataState = NoDataStateAlerting
+	assert.False(t, desired.SemanticEqual(actual))
+}
+
+func TestOutlierDetectorSemanticEqual(t *testing.T) {
+	a := OutlierDetector{Name: "Test", QueryParams: map[string]interface{}{"maxDataPoints": 100}}
+	b := OutlierDetector{Name: "Test", QueryParams: map[string]interface{}{"maxDataPoints": float64(100)}}
+	assert.True(t, a.SemanticEqual(b))
+}
@@ -1,9 +1,7 @@
 package mlapi
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 )
 
 type OutlierAlgorithmConfig struct {
@@ -45,62 +43,35 @@ type OutlierDetector struct {
 	// https://grafana.com/docs/grafana-cloud/machine-learning/outlier-detection/ for the
 	// options.
 	Algorithm OutlierAlgorithm `json:"algorithm"`
+
+	// Paused is set when the outlier detector and its alerts are not being
+	// evaluated. It is read only and is not sent when the outlier detector is
+	// created or updated; use PauseOutlierDetector and ResumeOutlierDetector
+	// to change it.
+	Paused bool `json:"paused,omitempty"`
 }
 
 // NewOutlierDetector creates an outlier detector.
 func (c *Client) NewOutlierDetector(ctx context.Context, outlier OutlierDetector) (OutlierDetector, error) {
-	data, err := json.Marshal(outlier)
-	if err != nil {
-		return OutlierDetector{}, err
-	}
-
-	result := responseWrapper[OutlierDetector]{}
-	err = c.request(ctx, "POST", "/manage/api/v1/outliers", nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return OutlierDetector{}, err
-	}
-	return result.Data, nil
+	return c.OutlierDetectorResource().Create(ctx, outlier)
 }
 
 // OutlierDetectors fetches all existing outlier detectors.
 func (c *Client) OutlierDetectors(ctx context.Context) ([]OutlierDetector, error) {
-	result := responseWrapper[[]OutlierDetector]{}
-	err := c.request(ctx, "GET", "/manage/api/v1/outliers", nil, nil, &result)
-	if err != nil {
-		return []OutlierDetector{}, err
-	}
-	return result.Data, nil
+	return c.OutlierDetectorResource().List(ctx)
 }
 
 // OutlierDetector fetches an existing outlier detector.
 func (c *Client) OutlierDetector(ctx context.Context, id string) (OutlierDetector, error) {
-	result := responseWrapper[OutlierDetector]{}
-	err := c.request(ctx, "GET", "/manage/api/v1/outliers/"+id, nil, nil, &result)
-	if err != nil {
-		return OutlierDetector{}, err
-	}
-	return result.Data, nil
+	return c.OutlierDetectorResource().Get(ctx, id)
 }
 
 // UpdateOutlierDetector updates an outlier detector.
 func (c *Client) UpdateOutlierDetector(ctx context.Context, outlier OutlierDetector) (OutlierDetector, error) {
-	id := outlier.ID
-	// Clear the ID before sending otherwise validation fails.
-	outlier.ID = ""
-	data, err := json.Marshal(outlier)
-	if err != nil {
-		return OutlierDetector{}, err
-	}
-
-	result := responseWrapper[OutlierDetector]{}
-	err = c.request(ctx, "POST", "/manage/api/v1/outliers/"+id, nil, bytes.NewReader(data), &result)
-	if err != nil {
-		return OutlierDetector{}, err
-	}
-	return result.Data, nil
+	return c.OutlierDetectorResource().Update(ctx, outlier)
 }
 
 // DeleteOutlierDetector deletes an outlier detector.
 func (c *Client) DeleteOutlierDetector(ctx context.Context, id string) error {
-	return c.request(ctx, "DELETE", "/manage/api/v1/outliers/"+id, nil, nil, nil)
+	return c.OutlierDetectorResource().Delete(ctx, id)
 }
@@ -0,0 +1,156 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+)
+
+// LabelSelector selects resources whose labels contain every key and value in
+// the selector. An empty selector selects everything.
+type LabelSelector map[string]string
+
+// Matches reports whether labels satisfy the selector. Label values are
+// compared using their string representation.
+func (s LabelSelector) Matches(labels map[string]interface{}) bool {
+	for k, want := range s {
+		got, ok := labels[k]
+		if !ok || fmt.Sprint(got) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// PauseJob stops training and alert evaluation for a machine learning job
+// without deleting it. Its alerts and holiday links are kept.
+func (c *Client) PauseJob(ctx context.Context, id string) (Job, error) {
+	return c.setJobPaused(ctx, id, "pause")
+}
+
+// ResumeJob resumes training and alert evaluation of a paused machine learning
+// job.
+func (c *Client) ResumeJob(ctx context.Context, id string) (Job, error) {
+	return c.setJobPaused(ctx, id, "resume")
+}
+
+func (c *Client) setJobPaused(ctx context.Context, id, action string) (Job, error) {
+	result := responseWrapper[Job]{}
+	err := c.request(ctx, "POST", path.Join(jobsPath, id, action), nil, nil, &result)
+	if err != nil {
+		return Job{}, err
+	}
+	return result.Data, nil
+}
+
+// PauseOutlierDetector stops evaluation of an outlier detector and its alerts
+// without deleting it.
+func (c *Client) PauseOutlierDetector(ctx context.Context, id string) (OutlierDetector, error) {
+	return c.setOutlierDetectorPaused(ctx, id, "pause")
+}
+
+// ResumeOutlierDetector resumes evaluation of a paused outlier detector.
+func (c *Client) ResumeOutlierDetector(ctx context.Context, id string) (OutlierDetector, error) {
+	return c.setOutlierDetectorPaused(ctx, id, "resume")
+}
+
+func (c *Client) setOutlierDetectorPaused(ctx context.Context, id, action string) (OutlierDetector, error) {
+	result := responseWrapper[OutlierDetector]{}
+	err := c.request(ctx, "POST", path.Join(outliersPath, id, action), nil, nil, &result)
+	if err != nil {
+		return OutlierDetector{}, err
+	}
+	return result.Data, nil
+}
+
+// PauseJobs pauses every machine learning job whose CustomLabels match
+// selector and which is not already paused. It carries on past jobs that fail
+// to pause, returning the jobs that were paused along with the joined errors.
+func (c *Client) PauseJobs(ctx context.Context, selector LabelSelector) ([]Job, error) {
+	return c.setJobsPaused(ctx, selector, true)
+}
+
+// ResumeJobs resumes every paused machine learning job whose CustomLabels
+// match selector. Errors are handled as in PauseJobs.
+func (c *Client) ResumeJobs(ctx context.Context, selector LabelSelector) ([]Job, error) {
+	return c.setJobsPaused(ctx, selector, false)
+}
+
+func (c *Client) setJobsPaused(ctx context.Context, selector LabelSelector, paused bool) ([]Job, error) {
+	jobs, err := c.Jobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changed []Job
+		errs    []error
+	)
+	for _, job := range jobs {
+		if job.Paused == paused || !selector.Matches(job.CustomLabels) {
+			continue
+		}
+		var updated Job
+		if paused {
+			updated, err = c.PauseJob(ctx, job.ID)
+		} else {
+			updated, err = c.ResumeJob(ctx, job.ID)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.ID, err))
+			continue
+		}
+		changed = append(changed, updated)
+	}
+	return changed, errors.Join(errs...)
+}
+
+// PauseOutlierDetectors pauses every outlier detector matching opts which is
+// not already paused. Outlier detectors have no labels, so they are selected
+// with ListOptions rather than a LabelSelector. Errors are handled as in
+// PauseJobs.
+func (c *Client) PauseOutlierDetectors(ctx context.Context, opts ListOptions) ([]OutlierDetector, error) {
+	return c.setOutlierDetectorsPaused(ctx, opts, true)
+}
+
+// ResumeOutlierDetectors resumes every paused outlier detector matching opts.
+// Errors are handled as in PauseJobs.
+func (c *Client) ResumeOutlierDetectors(ctx context.Context, opts ListOptions) ([]OutlierDetector, error) {
+	return c.setOutlierDetectorsPaused(ctx, opts, false)
+}
+
+func (c *Client) setOutlierDetectorsPaused(ctx context.Context, opts ListOptions, paused bool) ([]OutlierDetector, error) {
+	var outliers []OutlierDetector
+	for outlier, err := range c.OutlierDetectorResource().All(ctx, opts) {
+		if err != nil {
+			return nil, err
+		}
+		outliers = append(outliers, outlier)
+	}
+
+	var (
+		changed []OutlierDetector
+		errs    []error
+	)
+	for _, outlier := range outliers {
+		if outlier.Paused == paused {
+			continue
+		}
+		var (
+			updated OutlierDetector
+			err     error
+		)
+		if paused {
+			updated, err = c.PauseOutlierDetector(ctx, outlier.ID)
+		} else {
+			updated, err = c.ResumeOutlierDetector(ctx, outlier.ID)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outlier detector %s: %w", outlier.ID, err))
+			continue
+		}
+		changed = append(changed, updated)
+	}
+	return changed, errors.Join(errs...)
+}
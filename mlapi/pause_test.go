@@ -0,0 +1,162 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseJob(t *testing.T) {
+	id := "8b154ff8-3d64-4b79-8b26-02b4baeb44e4"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		enc := json.NewEncoder(w)
+		switch r.URL.Path {
+		case "/manage/api/v1/jobs/" + id + "/pause":
+			_ = enc.Encode(responseWrapper[Job]{Data: Job{ID: id, Paused: true}})
+		case "/manage/api/v1/jobs/" + id + "/resume":
+			_ = enc.Encode(responseWrapper[Job]{Data: Job{ID: id}})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	job, err := c.PauseJob(ctx, id)
+	require.NoError(t, err)
+	assert.True(t, job.Paused)
+
+	job, err = c.ResumeJob(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, job.Paused)
+}
+
+func TestPauseOutlierDetector(t *testing.T) {
+	id := "8b154ff8-3d64-4b79-8b26-02b4baeb44e4"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/manage/api/v1/outliers/"+id+"/pause" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(`{"status":"success","data":{"id":"` + id + `","paused":true}}`))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	outlier, err := c.PauseOutlierDetector(context.Background(), id)
+	require.NoError(t, err)
+	assert.True(t, outlier.Paused)
+}
+
+func TestPauseJobs(t *testing.T) {
+	jobs := []Job{
+		{ID: "1", CustomLabels: map[string]interface{}{"team": "a", "env": "prod"}},
+		{ID: "2", CustomLabels: map[string]interface{}{"team": "a", "env": "dev"}},
+		{ID: "3", CustomLabels: map[string]interface{}{"team": "a", "env": "prod"}, Paused: true},
+		{ID: "4", CustomLabels: map[string]interface{}{"team": "b", "env": "prod"}},
+		{ID: "5", CustomLabels: map[string]interface{}{"team": "a", "env": "prod"}},
+	}
+	var paused []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/manage/api/v1/jobs":
+			_ = enc.Encode(responseWrapper[[]Job]{Data: jobs})
+		case r.Method == "POST" && r.URL.Path == "/manage/api/v1/jobs/5/pause":
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		case r.Method == "POST" && r.URL.Path == "/manage/api/v1/jobs/1/pause":
+			paused = append(paused, "1")
+			_ = enc.Encode(responseWrapper[Job]{Data: Job{ID: "1", Paused: true}})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	changed, err := c.PauseJobs(context.Background(), LabelSelector{"team": "a", "env": "prod"})
+	require.EqualError(t, err, "job 5: status: 500, body: internal error\n")
+	assert.Equal(t, []string{"1"}, paused)
+	assert.Equal(t, []Job{{ID: "1", Paused: true}}, changed)
+}
+
+func TestResumeOutlierDetectors(t *testing.T) {
+	outliers := []OutlierDetector{
+		{ID: "1", Name: "checkout latency", Paused: true},
+		{ID: "2", Name: "checkout errors"},
+		{ID: "3", Name: "search latency", Paused: true},
+	}
+	var resumed []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/manage/api/v1/outliers":
+			assert.Equal(t, "checkout", r.URL.Query().Get("namePrefix"))
+			_ = enc.Encode(responseWrapper[[]OutlierDetector]{Data: outliers})
+		case r.Method == "POST" && r.URL.Path == "/manage/api/v1/outliers/1/resume":
+			resumed = append(resumed, "1")
+			_ = enc.Encode(responseWrapper[OutlierDetector]{Data: OutlierDetector{ID: "1"}})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	// The server ignores the filter; the client applies it.
+	changed, err := c.ResumeOutlierDetectors(context.Background(), ListOptions{NamePrefix: "checkout"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, resumed)
+	assert.Equal(t, []OutlierDetector{{ID: "1"}}, changed)
+}
+
+func TestUpdateOmitsPaused(t *testing.T) {
+	var bodies []map[string]interface{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		_, err := w.Write([]byte(`{"status":"success","data":{"id":"1","paused":true}}`))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	job, err := c.UpdateJob(context.Background(), Job{ID: "1", Name: "cpu", Paused: true})
+	require.NoError(t, err)
+	assert.True(t, job.Paused)
+	outlier, err := c.UpdateOutlierDetector(context.Background(), OutlierDetector{ID: "1", Name: "latency", Paused: true})
+	require.NoError(t, err)
+	assert.True(t, outlier.Paused)
+
+	require.Len(t, bodies, 2)
+	for _, body := range bodies {
+		assert.Contains(t, body, "name")
+		assert.NotContains(t, body, "paused")
+	}
+}
@@ -0,0 +1,98 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// PerSeriesOptions configure ForecastJobPerSeries.
+type PerSeriesOptions struct {
+	// Concurrency is the number of series forecast in parallel. Defaults to
+	// DefaultBulkConcurrency.
+	Concurrency int
+}
+
+// ForecastJobPerSeries runs an ephemeral forecast for a job whose query
+// returns more than one series, which ForecastJob rejects.
+//
+// It runs the job's query over its training window to find the series, then
+// forecasts each series with ForecastJob, using a copy of the job whose query
+// is filtered to return only that series. Only Prometheus queries are
+// supported.
+//
+// It returns the forecasts of the series that succeeded, each labeled with the
+// labels of its series, along with an error joining the errors of the series
+// that failed.
+func (c *Client) ForecastJobPerSeries(ctx context.Context, spec ForecastRequest, opts PerSeriesOptions) ([]Forecast, error) {
+	job := spec.Job
+	if job.DatasourceType != DatasourceTypePrometheus {
+		return nil, fmt.Errorf("per series forecasts are only supported for %s queries, not %q", DatasourceTypePrometheus, job.DatasourceType)
+	}
+	expr, _ := job.QueryParams["expr"].(string)
+	if expr == "" {
+		return nil, errors.New("job query has no expr")
+	}
+
+	series, err := c.jobSeries(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("finding series: %w", err)
+	}
+	for i, labels := range series {
+		series[i] = withoutLabel(labels, nameLabel)
+	}
+
+	results, _ := runBulk(ctx, "", "forecast", series, BulkOptions{Concurrency: opts.Concurrency},
+		func(ctx context.Context, labels data.Labels) (backend.QueryDataResponse, error) {
+			seriesSpec := spec
+			seriesSpec.Job = withExpr(job, filterSeries(expr, labels))
+			return c.ForecastJob(ctx, seriesSpec)
+		}, nil, nil)
+
+	var (
+		forecasts []Forecast
+		errs      []error
+	)
+	for i, res := range results {
+		if res.Err == nil {
+			decoded, decodeErr := DecodeForecasts(res.Item)
+			for _, fs := range decoded {
+				for _, f := range fs {
+					for k, v := range series[i] {
+						f.Labels[k] = v
+					}
+					forecasts = append(forecasts, f)
+				}
+			}
+			res.Err = decodeErr
+		}
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("series {%s}: %w", series[i], res.Err))
+		}
+	}
+	return forecasts, errors.Join(errs...)
+}
+
+func withoutLabel(labels data.Labels, name string) data.Labels {
+	copied := make(data.Labels, len(labels))
+	for k, v := range labels {
+		if k != name {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// withExpr returns a copy of job whose query has the given expr.
+func withExpr(job Job, expr string) Job {
+	params := make(map[string]interface{}, len(job.QueryParams)+1)
+	for k, v := range job.QueryParams {
+		params[k] = v
+	}
+	params["expr"] = expr
+	job.QueryParams = params
+	return job
+}
@@ -0,0 +1,75 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecastJobPerSeries(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		exprs []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case dsQueryPath:
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+				seriesFrame(data.Labels{nameLabel: "cpu", "pod": "a"}, 1, 2),
+				seriesFrame(data.Labels{nameLabel: "cpu", "pod": "b"}, 1, 2),
+			}}
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/predict/api/v1/forecast":
+			spec := ForecastRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&spec))
+			expr := spec.Job.QueryParams["expr"].(string)
+			mu.Lock()
+			exprs = append(exprs, expr)
+			mu.Unlock()
+			if strings.Contains(expr, `"pod", "b"`) {
+				http.Error(w, "training failed", http.StatusBadRequest)
+				return
+			}
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+				data.NewFrame("",
+					data.NewField("time", nil, []time.Time{time.Unix(0, 0)}),
+					data.NewField("yhat", nil, []float64{1}),
+				),
+			}}
+			_ = json.NewEncoder(w).Encode(responseWrapper[*backend.QueryDataResponse]{Data: resp})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	spec := ForecastRequest{Job: prometheusJob(t, "cpu", "cpu", "prom", "rate(cpu[5m])")}
+	forecasts, err := c.ForecastJobPerSeries(context.Background(), spec, PerSeriesOptions{Concurrency: 2})
+	require.EqualError(t, err, `series {pod=b}: status: 400, body: training failed`+"\n")
+	require.Len(t, forecasts, 1)
+	assert.Equal(t, data.Labels{"pod": "a"}, forecasts[0].Labels)
+	assert.Equal(t, []float64{1}, forecasts[0].Predicted)
+	assert.ElementsMatch(t, []string{
+		"(rate(cpu[5m])\n) and on(pod) label_replace(vector(1), \"pod\", \"a\", \"\", \"\")",
+		"(rate(cpu[5m])\n) and on(pod) label_replace(vector(1), \"pod\", \"b\", \"\", \"\")",
+	}, exprs)
+
+	spec.Job.DatasourceType = DatasourceTypeLoki
+	_, err = c.ForecastJobPerSeries(context.Background(), spec, PerSeriesOptions{})
+	require.EqualError(t, err, `per series forecasts are only supported for prometheus queries, not "loki"`)
+}
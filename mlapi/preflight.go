@@ -0,0 +1,129 @@
+package mlapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// SeriesLimitError is returned by preflight checks when a query returns more
+// series than the tenant's limit.
+type SeriesLimitError struct {
+	// Kind is the kind of resource: job or outlier detector.
+	Kind  string
+	Limit uint
+	// Series are the labels of every series returned by the query.
+	Series []data.Labels
+}
+
+func (e *SeriesLimitError) Error() string {
+	return fmt.Sprintf("%s query returns %d series, more than the limit of %d", e.Kind, len(e.Series), e.Limit)
+}
+
+// PreflightJob checks a job before it is created. It runs the job's query over
+// the training window through the job's datasource and returns the labels of
+// every series in the result. If there are more series than
+// TenantInfo.MaxSeriesPerJob, it returns a *SeriesLimitError listing them.
+func (c *Client) PreflightJob(ctx context.Context, job Job) ([]data.Labels, error) {
+	info, err := c.TenantInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tenant info: %w", err)
+	}
+	series, err := c.jobSeries(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	return checkSeriesLimit("job", series, info.MaxSeriesPerJob)
+}
+
+// checkJobSeriesLimit checks a job against the series limit of info, as
+// PreflightJob does.
+func (c *Client) checkJobSeriesLimit(ctx context.Context, job Job, info TenantInfo) error {
+	series, err := c.jobSeries(ctx, job)
+	if err != nil {
+		return fmt.Errorf("checking series limit: %w", err)
+	}
+	_, err = checkSeriesLimit("job", series, info.MaxSeriesPerJob)
+	return err
+}
+
+// jobSeries runs the job's query over its training window and returns the
+// labels of every series in the result.
+func (c *Client) jobSeries(ctx context.Context, job Job) ([]data.Labels, error) {
+	to := time.Now()
+	frames, err := c.queryDatasource(ctx, datasourceQuery{
+		datasourceUID:  job.DatasourceUID,
+		datasourceID:   job.DatasourceID,
+		datasourceType: job.DatasourceType,
+		model:          job.QueryParams,
+		from:           to.Add(-time.Duration(job.TrainingWindow) * time.Second),
+		to:             to,
+		interval:       time.Duration(job.Interval) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seriesLabels(frames), nil
+}
+
+// OutlierSeriesLimitWindow is the window outlier detector queries are run
+// over when bulk operations check them against TenantInfo.MaxSeriesPerOutlier.
+// Outlier detectors have no training window, so a short recent window is used
+// to count the series currently reporting.
+const OutlierSeriesLimitWindow = time.Hour
+
+// PreflightOutlierDetector checks an outlier detector before it is created.
+// It runs the detector's query over the given window, and compares the number
+// of series with TenantInfo.MaxSeriesPerOutlier. See PreflightJob.
+func (c *Client) PreflightOutlierDetector(ctx context.Context, outlier OutlierDetector, window time.Duration) ([]data.Labels, error) {
+	info, err := c.TenantInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tenant info: %w", err)
+	}
+	series, err := c.outlierDetectorSeries(ctx, outlier, window)
+	if err != nil {
+		return nil, err
+	}
+	return checkSeriesLimit("outlier detector", series, info.MaxSeriesPerOutlier)
+}
+
+// checkOutlierDetectorSeriesLimit checks an outlier detector against the
+// series limit of info over OutlierSeriesLimitWindow.
+func (c *Client) checkOutlierDetectorSeriesLimit(ctx context.Context, outlier OutlierDetector, info TenantInfo) error {
+	series, err := c.outlierDetectorSeries(ctx, outlier, OutlierSeriesLimitWindow)
+	if err != nil {
+		return fmt.Errorf("checking series limit: %w", err)
+	}
+	_, err = checkSeriesLimit("outlier detector", series, info.MaxSeriesPerOutlier)
+	return err
+}
+
+// outlierDetectorSeries runs the outlier detector's query over window and
+// returns the labels of every series in the result.
+func (c *Client) outlierDetectorSeries(ctx context.Context, outlier OutlierDetector, window time.Duration) ([]data.Labels, error) {
+	to := time.Now()
+	frames, err := c.queryDatasource(ctx, datasourceQuery{
+		datasourceUID:  outlier.DatasourceUID,
+		datasourceID:   outlier.DatasourceID,
+		datasourceType: outlier.DatasourceType,
+		model:          outlier.QueryParams,
+		from:           to.Add(-window),
+		to:             to,
+		interval:       time.Duration(outlier.Interval) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seriesLabels(frames), nil
+}
+
+// checkSeriesLimit returns an error if there are more series than limit. A
+// limit of zero is unlimited.
+func checkSeriesLimit(kind string, series []data.Labels, limit uint) ([]data.Labels, error) {
+	if limit > 0 && uint(len(series)) > limit {
+		return series, &SeriesLimitError{Kind: kind, Limit: limit, Series: series}
+	}
+	return series, nil
+}
@@ -0,0 +1,124 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seriesFrame returns a frame with a single series.
+func seriesFrame(labels data.Labels, values ...float64) *data.Frame {
+	times := make([]time.Time, len(values))
+	for i := range values {
+		times[i] = time.Unix(int64(i*60), 0).UTC()
+	}
+	return data.NewFrame("",
+		data.NewField("time", nil, times),
+		data.NewField("value", labels, values),
+	)
+}
+
+func newPreflightServer(t *testing.T, queries *[]map[string]interface{}, frames ...*data.Frame) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case pluginResourcesPath + "/tenant/api/v1/info":
+			_ = json.NewEncoder(w).Encode(responseWrapper[TenantInfo]{Data: TenantInfo{MaxSeriesPerJob: 2, MaxSeriesPerOutlier: 5}})
+		case dsQueryPath:
+			body := struct {
+				From    string                   `json:"from"`
+				To      string                   `json:"to"`
+				Queries []map[string]interface{} `json:"queries"`
+			}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			*queries = append(*queries, body.Queries...)
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: frames}
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+}
+
+func TestPreflightJob(t *testing.T) {
+	var queries []map[string]interface{}
+	s := newPreflightServer(t, &queries,
+		seriesFrame(data.Labels{"instance": "a"}, 1, 2),
+		seriesFrame(data.Labels{"instance": "c"}, 1, 2),
+		seriesFrame(data.Labels{"instance": "b"}, 1, 2),
+	)
+	defer s.Close()
+
+	c, err := New(s.URL+pluginResourcesPath, Config{})
+	require.NoError(t, err)
+
+	job := prometheusJob(t, "cpu", "cpu", "prom", "rate(cpu[5m])")
+	series, err := c.PreflightJob(context.Background(), job)
+	limitErr := &SeriesLimitError{}
+	require.ErrorAs(t, err, &limitErr)
+	assert.EqualError(t, err, "job query returns 3 series, more than the limit of 2")
+	assert.Equal(t, []data.Labels{{"instance": "a"}, {"instance": "b"}, {"instance": "c"}}, series)
+	assert.Equal(t, series, limitErr.Series)
+
+	require.Len(t, queries, 1)
+	assert.Equal(t, "rate(cpu[5m])", queries[0]["expr"])
+	assert.Equal(t, "A", queries[0]["refId"])
+	assert.Equal(t, map[string]interface{}{"uid": "prom", "type": DatasourceTypePrometheus}, queries[0]["datasource"])
+	assert.EqualValues(t, job.Interval*1000, queries[0]["intervalMs"])
+	assert.EqualValues(t, job.TrainingWindow/job.Interval, queries[0]["maxDataPoints"])
+}
+
+func TestPreflightOutlierDetector(t *testing.T) {
+	var queries []map[string]interface{}
+	s := newPreflightServer(t, &queries,
+		seriesFrame(data.Labels{"instance": "a"}, 1, 2),
+		seriesFrame(data.Labels{"instance": "a"}, 3, 4),
+	)
+	defer s.Close()
+
+	c, err := New(s.URL+pluginResourcesPath, Config{})
+	require.NoError(t, err)
+
+	outlier := OutlierDetector{Name: "latency", DatasourceID: 7, Interval: 300, QueryParams: map[string]interface{}{"expr": "up"}}
+	series, err := c.PreflightOutlierDetector(context.Background(), outlier, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []data.Labels{{"instance": "a"}}, series)
+
+	require.Len(t, queries, 1)
+	assert.EqualValues(t, 7, queries[0]["datasourceId"])
+	assert.EqualValues(t, 12, queries[0]["maxDataPoints"])
+}
+
+func TestGrafanaURL(t *testing.T) {
+	c, err := New("https://example.grafana.net/api/plugins/grafana-ml-app/resources/", Config{})
+	require.NoError(t, err)
+	g, err := c.grafana()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.grafana.net", g.baseURL.String())
+
+	c, err = New("https://ml.example.com", Config{GrafanaURL: "https://grafana.example.com/sub"})
+	require.NoError(t, err)
+	g, err = c.grafana()
+	require.NoError(t, err)
+	assert.Equal(t, "https://grafana.example.com/sub", g.baseURL.String())
+}
+
+func TestSeriesLabelsSkipsNonNumericFields(t *testing.T) {
+	// Loki and SQL datasources return labels and other text as string fields
+	// alongside the values.
+	frame := data.NewFrame("",
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(60, 0)}),
+		data.NewField("line", nil, []string{"a", "b"}),
+		data.NewField("value", data.Labels{"instance": "a"}, []float64{1, 2}),
+		data.NewField("count", data.Labels{"instance": "b"}, []*int64{nil, nil}),
+	)
+	assert.Equal(t, []data.Labels{{"instance": "a"}, {"instance": "b"}}, seriesLabels(data.Frames{frame}))
+}
@@ -0,0 +1,43 @@
+package mlapi
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterSeries wraps a PromQL expression so that it only returns the series
+// with the given labels. It does not rewrite the selectors inside the
+// expression, which would change the result of binary operations, label
+// functions and aggregations over labels the expression itself produces.
+// Instead the result is intersected, with `and on(...)`, with a constant
+// vector carrying exactly those labels.
+func filterSeries(expr string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return expr
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	filter := "vector(1)"
+	for _, name := range names {
+		// The replacement of label_replace expands $ references.
+		value := strings.ReplaceAll(labels[name], "$", "$$")
+		filter = "label_replace(" + filter + ", " + strconv.Quote(name) + ", " + strconv.Quote(value) + `, "", "")`
+	}
+	// The newline ends a trailing comment in expr.
+	return "(" + expr + "\n) and on(" + strings.Join(names, ", ") + ") " + filter
+}
+
+// before wraps a PromQL expression so that it only returns points evaluated
+// before t. Range selectors only look back from the evaluation time, so none
+// of the returned points depend on samples at or after t, unless expr uses a
+// negative offset.
+func before(expr string, t time.Time) string {
+	// The newline ends a trailing comment in expr.
+	return "(" + expr + "\n) and on() (vector(time()) < " + strconv.FormatInt(t.Unix(), 10) + ")"
+}
@@ -0,0 +1,31 @@
+package mlapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterSeries(t *testing.T) {
+	labels := map[string]string{"pod": "a", "ns": `we"ird$1`}
+	filter := ` and on(ns, pod) label_replace(label_replace(vector(1), "ns", "we\"ird$$1", "", ""), "pod", "a", "", "")`
+	for _, expr := range []string{
+		`up`,
+		// Selectors are left alone, as b has no pod label to match on and
+		// dst is only produced by label_replace.
+		`a / on(pod) group_left b`,
+		`label_replace(up, "dst", "$1", "src", "(.*)")`,
+		`sum by (pod) (rate(http_requests_total[5m])) # per pod`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			assert.Equal(t, "("+expr+"\n)"+filter, filterSeries(expr, labels))
+		})
+	}
+
+	assert.Equal(t, "up", filterSeries("up", nil))
+}
+
+func TestBefore(t *testing.T) {
+	assert.Equal(t, "(rate(up[5m]) # per second\n) and on() (vector(time()) < 3600)", before("rate(up[5m]) # per second", time.Unix(3600, 0)))
+}
@@ -0,0 +1,378 @@
+package mlapi
+
+import (
+	"fmt"
+)
+
+// Datasource types with typed query parameters.
+const (
+	DatasourceTypePrometheus    = "prometheus"
+	DatasourceTypeLoki          = "loki"
+	DatasourceTypeGraphite      = "graphite"
+	DatasourceTypeInfluxDB      = "influxdb"
+	DatasourceTypeDatadog       = "grafana-datadog-datasource"
+	DatasourceTypeElasticsearch = "elasticsearch"
+)
+
+// defaultRefID is the refId used by Grafana for the first query of a panel.
+const defaultRefID = "A"
+
+// QueryParams are typed query parameters for a single datasource type. They
+// have the same shape as the query model Grafana sends to the datasource.
+//
+// Use Job.SetQueryParams or OutlierDetector.SetQueryParams to store them and
+// the matching TypedQueryParams methods to read them back.
+type QueryParams interface {
+	// DatasourceType returns the datasource type the query is for.
+	DatasourceType() string
+	// Validate checks the query has everything the datasource requires.
+	Validate() error
+}
+
+// PrometheusQuery is a query against a Prometheus datasource.
+type PrometheusQuery struct {
+	RefID string `json:"refId"`
+	// Expr is the PromQL expression.
+	Expr string `json:"expr"`
+	// Interval is the minimum step, such as `1m`. Empty uses the job
+	// interval.
+	Interval     string `json:"interval"`
+	LegendFormat string `json:"legendFormat"`
+	Exemplar     bool   `json:"exemplar"`
+}
+
+// NewPrometheusQuery returns a PrometheusQuery for a PromQL expression.
+func NewPrometheusQuery(expr string) PrometheusQuery {
+	return PrometheusQuery{RefID: defaultRefID, Expr: expr}
+}
+
+// DatasourceType implements QueryParams.
+func (q PrometheusQuery) DatasourceType() string {
+	return DatasourceTypePrometheus
+}
+
+// Validate implements QueryParams.
+func (q PrometheusQuery) Validate() error {
+	var errs ValidationErrors
+	if q.Expr == "" {
+		errs.add("expr", "is required")
+	}
+	return errs.err()
+}
+
+// LokiQuery is a metric query against a Loki datasource.
+type LokiQuery struct {
+	RefID string `json:"refId"`
+	// Expr is the LogQL metric expression, such as
+	// `sum(rate({app="foo"}[5m]))`.
+	Expr string `json:"expr"`
+	// QueryType is the type of the query; ML queries must be `range`.
+	QueryType string `json:"queryType"`
+}
+
+// NewLokiQuery returns a LokiQuery for a LogQL metric expression.
+func NewLokiQuery(expr string) LokiQuery {
+	return LokiQuery{RefID: defaultRefID, Expr: expr, QueryType: "range"}
+}
+
+// DatasourceType implements QueryParams.
+func (q LokiQuery) DatasourceType() string {
+	return DatasourceTypeLoki
+}
+
+// Validate implements QueryParams.
+func (q LokiQuery) Validate() error {
+	var errs ValidationErrors
+	if q.Expr == "" {
+		errs.add("expr", "is required")
+	}
+	if q.QueryType != "" && q.QueryType != "range" {
+		errs.add("queryType", "must be range, got %q", q.QueryType)
+	}
+	return errs.err()
+}
+
+// GraphiteQuery is a query against a Graphite datasource.
+type GraphiteQuery struct {
+	RefID string `json:"refId"`
+	// Target is the Graphite target expression.
+	Target string `json:"target"`
+}
+
+// NewGraphiteQuery returns a GraphiteQuery for a target expression.
+func NewGraphiteQuery(target string) GraphiteQuery {
+	return GraphiteQuery{RefID: defaultRefID, Target: target}
+}
+
+// DatasourceType implements QueryParams.
+func (q GraphiteQuery) DatasourceType() string {
+	return DatasourceTypeGraphite
+}
+
+// Validate implements QueryParams.
+func (q GraphiteQuery) Validate() error {
+	var errs ValidationErrors
+	if q.Target == "" {
+		errs.add("target", "is required")
+	}
+	return errs.err()
+}
+
+// InfluxDBQuery is a raw InfluxQL or Flux query against an InfluxDB
+// datasource.
+type InfluxDBQuery struct {
+	RefID string `json:"refId"`
+	// Query is the InfluxQL or Flux query text.
+	Query string `json:"query"`
+	// RawQuery must be true for InfluxQL queries written as text.
+	RawQuery     bool   `json:"rawQuery"`
+	ResultFormat string `json:"resultFormat"`
+}
+
+// NewInfluxDBQuery returns an InfluxDBQuery for a raw query.
+func NewInfluxDBQuery(query string) InfluxDBQuery {
+	return InfluxDBQuery{RefID: defaultRefID, Query: query, RawQuery: true, ResultFormat: "time_series"}
+}
+
+// DatasourceType implements QueryParams.
+func (q InfluxDBQuery) DatasourceType() string {
+	return DatasourceTypeInfluxDB
+}
+
+// Validate implements QueryParams.
+func (q InfluxDBQuery) Validate() error {
+	var errs ValidationErrors
+	if q.Query == "" {
+		errs.add("query", "is required")
+	}
+	if q.ResultFormat != "" && q.ResultFormat != "time_series" {
+		errs.add("resultFormat", "must be time_series, got %q", q.ResultFormat)
+	}
+	return errs.err()
+}
+
+// DatadogQuery is a metrics query against a Datadog datasource.
+type DatadogQuery struct {
+	RefID string `json:"refId"`
+	// Query is the Datadog metrics query, such as
+	// `avg:system.cpu.user{*}`.
+	Query string `json:"query"`
+	// QueryType is the type of the query; ML queries must be `metrics`.
+	QueryType string `json:"queryType"`
+}
+
+// NewDatadogQuery returns a DatadogQuery for a metrics query.
+func NewDatadogQuery(query string) DatadogQuery {
+	return DatadogQuery{RefID: defaultRefID, Query: query, QueryType: "metrics"}
+}
+
+// DatasourceType implements QueryParams.
+func (q DatadogQuery) DatasourceType() string {
+	return DatasourceTypeDatadog
+}
+
+// Validate implements QueryParams.
+func (q DatadogQuery) Validate() error {
+	var errs ValidationErrors
+	if q.Query == "" {
+		errs.add("query", "is required")
+	}
+	if q.QueryType != "" && q.QueryType != "metrics" {
+		errs.add("queryType", "must be metrics, got %q", q.QueryType)
+	}
+	return errs.err()
+}
+
+// ElasticsearchQuery is a metric query against an Elasticsearch datasource.
+// Metrics and BucketAggs use the same untyped structure as the Grafana query
+// editor.
+type ElasticsearchQuery struct {
+	RefID string `json:"refId"`
+	// Query is the Lucene query string.
+	Query      string                   `json:"query"`
+	TimeField  string                   `json:"timeField"`
+	Metrics    []map[string]interface{} `json:"metrics"`
+	BucketAggs []map[string]interface{} `json:"bucketAggs"`
+}
+
+// NewElasticsearchQuery returns an ElasticsearchQuery counting the documents
+// matching a Lucene query over a date histogram of timeField.
+func NewElasticsearchQuery(query, timeField string) ElasticsearchQuery {
+	return ElasticsearchQuery{
+		RefID:     defaultRefID,
+		Query:     query,
+		TimeField: timeField,
+		Metrics:   []map[string]interface{}{{"id": "1", "type": "count"}},
+		BucketAggs: []map[string]interface{}{{
+			"id":       "2",
+			"type":     "date_histogram",
+			"field":    timeField,
+			"settings": map[string]interface{}{"interval": "auto"},
+		}},
+	}
+}
+
+// DatasourceType implements QueryParams.
+func (q ElasticsearchQuery) DatasourceType() string {
+	return DatasourceTypeElasticsearch
+}
+
+// Validate implements QueryParams.
+func (q ElasticsearchQuery) Validate() error {
+	var errs ValidationErrors
+	if q.TimeField == "" {
+		errs.add("timeField", "is required")
+	}
+	if len(q.Metrics) == 0 {
+		errs.add("metrics", "at least one metric is required")
+	}
+	if len(q.BucketAggs) == 0 {
+		errs.add("bucketAggs", "a date_histogram bucket aggregation is required")
+	}
+	return errs.err()
+}
+
+// RawQueryParams holds the query parameters of a datasource type that has no
+// typed representation in this package. They are passed to the server
+// unchanged.
+type RawQueryParams struct {
+	Type   string
+	Params map[string]interface{}
+}
+
+// DatasourceType implements QueryParams.
+func (q RawQueryParams) DatasourceType() string {
+	return q.Type
+}
+
+// Validate implements QueryParams. Raw query parameters are not validated.
+func (q RawQueryParams) Validate() error {
+	return nil
+}
+
+// SetQueryParams sets the DatasourceType and QueryParams of the job from typed
+// query parameters. If the datasource type is unchanged, keys the typed
+// parameters do not model, such as the `datasource` key the Grafana query
+// editor adds, are kept, so that TypedQueryParams followed by SetQueryParams
+// loses nothing. RawQueryParams replace the query parameters entirely.
+func (j *Job) SetQueryParams(params QueryParams) error {
+	m, err := encodeQueryParams(params, j.DatasourceType, j.QueryParams)
+	if err != nil {
+		return err
+	}
+	j.DatasourceType = params.DatasourceType()
+	j.QueryParams = m
+	return nil
+}
+
+// TypedQueryParams parses the QueryParams of the job into the typed query
+// parameters for its DatasourceType. Datasource types without a typed
+// representation are returned as RawQueryParams.
+func (j Job) TypedQueryParams() (QueryParams, error) {
+	return decodeQueryParams(j.DatasourceType, j.QueryParams)
+}
+
+// SetQueryParams sets the DatasourceType and QueryParams of the outlier
+// detector from typed query parameters, keeping unmodeled keys as
+// Job.SetQueryParams does.
+func (o *OutlierDetector) SetQueryParams(params QueryParams) error {
+	m, err := encodeQueryParams(params, o.DatasourceType, o.QueryParams)
+	if err != nil {
+		return err
+	}
+	o.DatasourceType = params.DatasourceType()
+	o.QueryParams = m
+	return nil
+}
+
+// TypedQueryParams parses the QueryParams of the outlier detector into the
+// typed query parameters for its DatasourceType. Datasource types without a
+// typed representation are returned as RawQueryParams.
+func (o OutlierDetector) TypedQueryParams() (QueryParams, error) {
+	return decodeQueryParams(o.DatasourceType, o.QueryParams)
+}
+
+// encodeQueryParams encodes typed query parameters over a copy of the current
+// query parameters, if they are for the same datasource type.
+func encodeQueryParams(params QueryParams, currentType string, current map[string]interface{}) (map[string]interface{}, error) {
+	if raw, ok := params.(RawQueryParams); ok {
+		return raw.Params, nil
+	}
+	encoded, err := structToMap(params)
+	if err != nil {
+		return nil, err
+	}
+	if currentType != params.DatasourceType() || len(current) == 0 {
+		return encoded, nil
+	}
+	merged := make(map[string]interface{}, len(current)+len(encoded))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range encoded {
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+// decodeQueryParams decodes query parameters returned by the API. Keys the
+// Grafana query editor adds that have no typed field, such as `datasource`,
+// are not decoded, but SetQueryParams keeps them.
+func decodeQueryParams(datasourceType string, m map[string]interface{}) (QueryParams, error) {
+	var params QueryParams
+	var err error
+	switch datasourceType {
+	case DatasourceTypePrometheus:
+		q := PrometheusQuery{}
+		err = mapToStruct(m, &q, false)
+		params = q
+	case DatasourceTypeLoki:
+		q := LokiQuery{}
+		err = mapToStruct(m, &q, false)
+		params = q
+	case DatasourceTypeGraphite:
+		q := GraphiteQuery{}
+		err = mapToStruct(m, &q, false)
+		params = q
+	case DatasourceTypeInfluxDB:
+		q := InfluxDBQuery{}
+		err = mapToStruct(m, &q, false)
+		params = q
+	case DatasourceTypeDatadog:
+		q := DatadogQuery{}
+		err = mapToStruct(m, &q, false)
+		params = q
+	case DatasourceTypeElasticsearch:
+		q := ElasticsearchQuery{}
+		err = mapToStruct(m, &q, false)
+		params = q
+	default:
+		return RawQueryParams{Type: datasourceType, Params: m}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid queryParams for %s: %w", datasourceType, err)
+	}
+	return params, nil
+}
+
+// NewPrometheusJob returns a Prophet forecasting job for a PromQL expression
+// against the Prometheus datasource with the given UID. It uses a 5 minute
+// interval, trains daily on the last 90 days, and the default Prophet
+// hyperparameters; any field can be changed before the job is created.
+func NewPrometheusJob(name, metric, datasourceUID, expr string) (Job, error) {
+	job := Job{
+		Name:              name,
+		Metric:            metric,
+		DatasourceUID:     datasourceUID,
+		Interval:          300,
+		TrainingWindow:    90 * 24 * 60 * 60,
+		TrainingFrequency: 24 * 60 * 60,
+	}
+	if err := job.SetQueryParams(NewPrometheusQuery(expr)); err != nil {
+		return Job{}, fmt.Errorf("encoding Prometheus query params: %w", err)
+	}
+	if err := job.SetHyperParams(DefaultProphetHyperParams()); err != nil {
+		return Job{}, fmt.Errorf("encoding Prophet hyperparameters: %w", err)
+	}
+	return job, nil
+}
@@ -0,0 +1,94 @@
+package mlapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusQueryParams(t *testing.T) {
+	// Query params as returned by the API, including keys added by the
+	// Grafana query editor that have no typed field.
+	job := Job{}
+	require.NoError(t, json.Unmarshal([]byte(`{"datasourceType":"prometheus","queryParams":{"exemplar":true,"expr":"sum(up)","interval":"","legendFormat":"","refId":"A","datasource":{"type":"prometheus"}}}`), &job))
+	params, err := job.TypedQueryParams()
+	require.NoError(t, err)
+	assert.Equal(t, PrometheusQuery{RefID: "A", Expr: "sum(up)", Exemplar: true}, params)
+
+	other := Job{}
+	require.NoError(t, other.SetQueryParams(params))
+	assert.Equal(t, DatasourceTypePrometheus, other.DatasourceType)
+	roundTripped, err := other.TypedQueryParams()
+	require.NoError(t, err)
+	assert.Equal(t, params, roundTripped)
+
+	// Setting edited params on the job they came from keeps the keys they do
+	// not model, without changing the original map.
+	original := job.QueryParams
+	edited := params.(PrometheusQuery)
+	edited.Expr = "sum(up) by (job)"
+	require.NoError(t, job.SetQueryParams(edited))
+	assert.Equal(t, "sum(up) by (job)", job.QueryParams["expr"])
+	assert.Equal(t, map[string]interface{}{"type": "prometheus"}, job.QueryParams["datasource"])
+	assert.Equal(t, "sum(up)", original["expr"])
+
+	// Keys of another datasource type are dropped.
+	require.NoError(t, job.SetQueryParams(NewLokiQuery(`sum(rate({app="foo"}[5m]))`)))
+	assert.NotContains(t, job.QueryParams, "datasource")
+
+	assert.Equal(t, ValidationErrors{{Field: "expr", Message: "is required"}}, PrometheusQuery{}.Validate())
+}
+
+func TestQueryParamsConstructors(t *testing.T) {
+	for _, params := range []QueryParams{
+		NewPrometheusQuery("sum(up)"),
+		NewLokiQuery(`sum(rate({app="foo"}[5m]))`),
+		NewGraphiteQuery("sumSeries(app.*.requests)"),
+		NewInfluxDBQuery(`SELECT mean("value") FROM "cpu" WHERE $timeFilter GROUP BY time($__interval)`),
+		NewDatadogQuery("avg:system.cpu.user{*}"),
+		NewElasticsearchQuery("status:500", "@timestamp"),
+	} {
+		t.Run(params.DatasourceType(), func(t *testing.T) {
+			require.NoError(t, params.Validate())
+
+			outlier := OutlierDetector{}
+			require.NoError(t, outlier.SetQueryParams(params))
+			assert.Equal(t, params.DatasourceType(), outlier.DatasourceType)
+
+			// Round trip through JSON so numbers and nested values take the
+			// shapes they have in API responses.
+			data, err := json.Marshal(outlier)
+			require.NoError(t, err)
+			returned := OutlierDetector{}
+			require.NoError(t, json.Unmarshal(data, &returned))
+			decoded, err := returned.TypedQueryParams()
+			require.NoError(t, err)
+			assert.Equal(t, params, decoded)
+		})
+	}
+}
+
+func TestRawQueryParams(t *testing.T) {
+	job := Job{DatasourceType: "some-plugin-datasource", QueryParams: map[string]interface{}{"q": "x"}}
+	params, err := job.TypedQueryParams()
+	require.NoError(t, err)
+	assert.Equal(t, RawQueryParams{Type: "some-plugin-datasource", Params: job.QueryParams}, params)
+}
+
+func TestNewPrometheusJob(t *testing.T) {
+	job, err := NewPrometheusJob("Test Job", "test_job", "abcd1234", "sum(up)")
+	require.NoError(t, err)
+	require.NoError(t, job.Validate())
+	assert.Equal(t, AlgorithmProphet, job.Algorithm)
+	assert.Equal(t, "sum(up)", job.QueryParams["expr"])
+}
+
+// prometheusJob returns NewPrometheusJob's job, failing the test on error.
+func prometheusJob(t *testing.T, name, metric, datasourceUID, expr string) Job {
+	t.Helper()
+	job, err := NewPrometheusJob(name, metric, datasourceUID, expr)
+	require.NoError(t, err)
+	return job
+}
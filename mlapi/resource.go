@@ -0,0 +1,246 @@
+package mlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	jobsPath          = "/manage/api/v1/jobs"
+	systemJobsPath    = "/manage/api/v1/system-jobs"
+	outliersPath      = "/manage/api/v1/outliers"
+	holidaysPath      = "/manage/api/v1/holidays"
+	jobAlertsPath     = "/manage/api/v1/jobs/%s/alerts"
+	outlierAlertsPath = "/manage/api/v1/outliers/%s/alerts"
+)
+
+// Resource provides the create, read, update and delete operations shared by
+// every machine learning resource served by the management API.
+//
+// A Resource is bound to a path template. Templates for nested resources, such
+// as the alerts of a job, contain one %s verb per parent ID which must be
+// filled using In before the Resource is used.
+type Resource[T any] struct {
+	client   *Client
+	path     string
+	id       func(*T) *string
+	filter   func(T, ListOptions) bool
+	validate func(T) error
+	readOnly []string
+	limit    func(context.Context, T, TenantInfo) error
+}
+
+// NewResource creates a Resource for the given path template. id must return a
+// pointer to the ID field of a T; it is used to address existing items and to
+// clear the ID before sending an update.
+func NewResource[T any](c *Client, pathTemplate string, id func(*T) *string) *Resource[T] {
+	return &Resource[T]{
+		client: c,
+		path:   pathTemplate,
+		id:     id,
+	}
+}
+
+// In returns a copy of the Resource with the parent IDs of a nested path
+// template filled in.
+func (r *Resource[T]) In(parentIDs ...string) *Resource[T] {
+	args := make([]any, len(parentIDs))
+	for i, id := range parentIDs {
+		args[i] = id
+	}
+	copied := *r
+	copied.path = fmt.Sprintf(r.path, args...)
+	return &copied
+}
+
+// Path returns the collection path of the Resource.
+func (r *Resource[T]) Path() string {
+	return r.path
+}
+
+// ID returns the ID of item.
+func (r *Resource[T]) ID(item T) string {
+	return *r.id(&item)
+}
+
+func (r *Resource[T]) itemPath(id string) string {
+	return path.Join(r.path, id)
+}
+
+func (r *Resource[T]) validatePath() error {
+	if strings.Contains(r.path, "%") {
+		return fmt.Errorf("resource path %q is missing parent IDs", r.path)
+	}
+	return nil
+}
+
+// WithValidation returns a copy of the Resource that checks items with
+// validate before they are created or updated.
+func (r *Resource[T]) WithValidation(validate func(T) error) *Resource[T] {
+	copied := *r
+	copied.validate = validate
+	return &copied
+}
+
+// WithSeriesLimit returns a copy of the Resource whose bulk operations check
+// items with check before creating or updating them when
+// BulkOptions.CheckLimits is set.
+func (r *Resource[T]) WithSeriesLimit(check func(context.Context, T, TenantInfo) error) *Resource[T] {
+	copied := *r
+	copied.limit = check
+	return &copied
+}
+
+// WithReadOnlyFields returns a copy of the Resource that leaves the given JSON
+// fields out of the items it creates and updates, for fields the server sets
+// and only returns.
+func (r *Resource[T]) WithReadOnlyFields(fields ...string) *Resource[T] {
+	copied := *r
+	copied.readOnly = fields
+	return &copied
+}
+
+// encode returns the JSON body sent to create or update item.
+func (r *Resource[T]) encode(item T) ([]byte, error) {
+	data, err := json.Marshal(item)
+	if err != nil || len(r.readOnly) == 0 {
+		return data, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range r.readOnly {
+		delete(fields, field)
+	}
+	return json.Marshal(fields)
+}
+
+// Create creates a new item.
+func (r *Resource[T]) Create(ctx context.Context, item T) (T, error) {
+	var zero T
+	if err := r.validatePath(); err != nil {
+		return zero, err
+	}
+	if r.validate != nil {
+		if err := r.validate(item); err != nil {
+			return zero, err
+		}
+	}
+	data, err := r.encode(item)
+	if err != nil {
+		return zero, err
+	}
+
+	result := responseWrapper[T]{}
+	err = r.client.request(ctx, "POST", r.path, nil, bytes.NewReader(data), &result)
+	if err != nil {
+		return zero, err
+	}
+	return result.Data, nil
+}
+
+// List fetches all existing items, following pagination if the server
+// paginates its results.
+func (r *Resource[T]) List(ctx context.Context) ([]T, error) {
+	return collect(r.All(ctx, ListOptions{}))
+}
+
+// Get fetches an existing item.
+func (r *Resource[T]) Get(ctx context.Context, id string) (T, error) {
+	var zero T
+	if err := r.validatePath(); err != nil {
+		return zero, err
+	}
+	result := responseWrapper[T]{}
+	err := r.client.request(ctx, "GET", r.itemPath(id), nil, nil, &result)
+	if err != nil {
+		return zero, err
+	}
+	return result.Data, nil
+}
+
+// Update updates an existing item, addressed by its ID.
+func (r *Resource[T]) Update(ctx context.Context, item T) (T, error) {
+	var zero T
+	if err := r.validatePath(); err != nil {
+		return zero, err
+	}
+	if r.validate != nil {
+		if err := r.validate(item); err != nil {
+			return zero, err
+		}
+	}
+	idField := r.id(&item)
+	id := *idField
+	// Clear the ID before sending otherwise validation fails.
+	*idField = ""
+	data, err := r.encode(item)
+	if err != nil {
+		return zero, err
+	}
+
+	result := responseWrapper[T]{}
+	err = r.client.request(ctx, "POST", r.itemPath(id), nil, bytes.NewReader(data), &result)
+	if err != nil {
+		return zero, err
+	}
+	return result.Data, nil
+}
+
+// Delete deletes an existing item.
+func (r *Resource[T]) Delete(ctx context.Context, id string) error {
+	if err := r.validatePath(); err != nil {
+		return err
+	}
+	return r.client.request(ctx, "DELETE", r.itemPath(id), nil, nil, nil)
+}
+
+// JobResource returns the Resource for machine learning jobs. Jobs are
+// validated before they are sent if Config.ValidateJobs is set.
+func (c *Client) JobResource() *Resource[Job] {
+	r := NewResource(c, jobsPath, func(j *Job) *string { return &j.ID }).WithFilter(jobMatches).WithReadOnlyFields("paused").
+		WithSeriesLimit(c.checkJobSeriesLimit)
+	if c.config.ValidateJobs {
+		r = r.WithValidation(Job.Validate)
+	}
+	return r
+}
+
+// SystemJobResource returns the Resource for system machine learning jobs.
+// Jobs are validated before they are sent if Config.ValidateJobs is set.
+func (c *Client) SystemJobResource() *Resource[Job] {
+	r := NewResource(c, systemJobsPath, func(j *Job) *string { return &j.ID }).WithFilter(jobMatches).WithReadOnlyFields("paused").
+		WithSeriesLimit(c.checkJobSeriesLimit)
+	if c.config.ValidateJobs {
+		r = r.WithValidation(Job.ValidateSystem)
+	}
+	return r
+}
+
+// OutlierDetectorResource returns the Resource for outlier detectors.
+func (c *Client) OutlierDetectorResource() *Resource[OutlierDetector] {
+	return NewResource(c, outliersPath, func(o *OutlierDetector) *string { return &o.ID }).
+		WithFilter(outlierDetectorMatches).WithReadOnlyFields("paused").
+		WithSeriesLimit(c.checkOutlierDetectorSeriesLimit)
+}
+
+// HolidayResource returns the Resource for holidays.
+func (c *Client) HolidayResource() *Resource[Holiday] {
+	return NewResource(c, holidaysPath, func(h *Holiday) *string { return &h.ID }).WithFilter(holidayMatches)
+}
+
+// JobAlertResource returns the Resource for the alerts of a job.
+func (c *Client) JobAlertResource(jobID string) *Resource[Alert] {
+	return NewResource(c, jobAlertsPath, func(a *Alert) *string { return &a.ID }).In(jobID)
+}
+
+// OutlierAlertResource returns the Resource for the alerts of an outlier
+// detector.
+func (c *Client) OutlierAlertResource(outlierID string) *Resource[Alert] {
+	return NewResource(c, outlierAlertsPath, func(a *Alert) *string { return &a.ID }).In(outlierID)
+}
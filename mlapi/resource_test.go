@@ -0,0 +1,84 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWidget struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+func TestResource(t *testing.T) {
+	parentID := "8b154ff8-3d64-4b79-8b26-02b4baeb44e4"
+	widgetID := "5218f38f-569b-448f-b81d-578173412195"
+	widget := testWidget{Name: "test widget"}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/parents/"+parentID+"/widgets":
+			parsed := testWidget{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&parsed))
+			assert.Equal(t, widget, parsed)
+			parsed.ID = widgetID
+			_ = enc.Encode(responseWrapper[testWidget]{Data: parsed})
+		case r.Method == "GET" && r.URL.Path == "/parents/"+parentID+"/widgets":
+			_ = enc.Encode(responseWrapper[[]testWidget]{Data: []testWidget{{ID: widgetID, Name: widget.Name}}})
+		case r.Method == "GET" && r.URL.Path == "/parents/"+parentID+"/widgets/"+widgetID:
+			_ = enc.Encode(responseWrapper[testWidget]{Data: testWidget{ID: widgetID, Name: widget.Name}})
+		case r.Method == "POST" && r.URL.Path == "/parents/"+parentID+"/widgets/"+widgetID:
+			parsed := testWidget{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&parsed))
+			if parsed.ID != "" {
+				http.Error(w, "id should be empty when updating", http.StatusBadRequest)
+				return
+			}
+			parsed.ID = widgetID
+			_ = enc.Encode(responseWrapper[testWidget]{Data: parsed})
+		case r.Method == "DELETE" && r.URL.Path == "/parents/"+parentID+"/widgets/"+widgetID:
+			_, err := w.Write([]byte("successfully deleted"))
+			require.NoError(t, err)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	widgets := NewResource(c, "/parents/%s/widgets", func(w *testWidget) *string { return &w.ID })
+
+	_, err = widgets.List(ctx)
+	require.EqualError(t, err, `resource path "/parents/%s/widgets" is missing parent IDs`)
+
+	widgets = widgets.In(parentID)
+	assert.Equal(t, "/parents/"+parentID+"/widgets", widgets.Path())
+
+	created, err := widgets.Create(ctx, widget)
+	require.NoError(t, err)
+	assert.Equal(t, widgetID, widgets.ID(created))
+
+	all, err := widgets.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []testWidget{created}, all)
+
+	fetched, err := widgets.Get(ctx, widgetID)
+	require.NoError(t, err)
+	assert.Equal(t, created, fetched)
+
+	created.Name = "renamed widget"
+	updated, err := widgets.Update(ctx, created)
+	require.NoError(t, err)
+	assert.Equal(t, created, updated)
+
+	require.NoError(t, widgets.Delete(ctx, widgetID))
+}
@@ -0,0 +1,210 @@
+package mlapi
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+)
+
+// JobState is the training state of a machine learning job.
+type JobState string
+
+const (
+	// JobStatePending means a training is scheduled but has not completed.
+	JobStatePending JobState = "pending"
+	// JobStateTraining means a training is in progress.
+	JobStateTraining JobState = "training"
+	// JobStateSuccess means the last training completed successfully.
+	JobStateSuccess JobState = "success"
+	// JobStateError means the last training failed.
+	JobStateError JobState = "error"
+)
+
+// JobStatus is the training status of a machine learning job. It is made of
+// the training fields the API returns alongside every job, which Job does not
+// decode.
+type JobStatus struct {
+	ID string `json:"id"`
+	// State is the scheduling state of the job, such as JobStatePending while
+	// a training is due.
+	State JobState `json:"status"`
+	// TrainingScheduledAt is when the pending training was scheduled, if any.
+	TrainingScheduledAt *time.Time `json:"trainingScheduledAt"`
+	// LastTrainedAt is when the last training completed, if any.
+	LastTrainedAt *time.Time `json:"trainingCompletedAt"`
+	// NextTrainingAt is when the next training is due.
+	NextTrainingAt *time.Time `json:"nextTrainingAt"`
+	// LastTrainingStatus is the outcome of the last completed training, or
+	// empty if the job has not completed a training.
+	LastTrainingStatus JobState `json:"lastTrainingStatus"`
+	// TrainingResult describes the result of the last training, such as the
+	// error of a failed training.
+	TrainingResult string `json:"trainingResult"`
+	// TrainingFailures is the number of consecutive failed trainings.
+	TrainingFailures uint `json:"trainingFailures"`
+	// SeriesCount is the number of series the last successful training
+	// produced models for. The job does not include it, so it is taken from
+	// the most recent successful Training in the job's history.
+	SeriesCount uint `json:"-"`
+}
+
+// Done reports whether the status is final: no training is pending or in
+// progress, and the last training either succeeded or failed.
+func (s JobStatus) Done() bool {
+	if s.State == JobStatePending || s.State == JobStateTraining {
+		return false
+	}
+	return s.LastTrainingStatus == JobStateSuccess || s.LastTrainingStatus == JobStateError
+}
+
+// TrainingError is returned by WaitForTraining when a training fails.
+type TrainingError struct {
+	Status JobStatus
+}
+
+func (e *TrainingError) Error() string {
+	return fmt.Sprintf("training of job %s failed: %s", e.Status.ID, e.Status.TrainingResult)
+}
+
+// JobStatus fetches the training status of a machine learning job. It fetches
+// the job, as Job does, and decodes its training fields. If the last training
+// succeeded, it also fetches the job's trainings to fill in SeriesCount.
+func (c *Client) JobStatus(ctx context.Context, id string) (JobStatus, error) {
+	status, err := c.jobStatus(ctx, id)
+	if err != nil {
+		return JobStatus{}, err
+	}
+	return c.withSeriesCount(ctx, status)
+}
+
+func (c *Client) jobStatus(ctx context.Context, id string) (JobStatus, error) {
+	result := responseWrapper[JobStatus]{}
+	err := c.request(ctx, "GET", c.JobResource().itemPath(id), nil, nil, &result)
+	if err != nil {
+		return JobStatus{}, err
+	}
+	return result.Data, nil
+}
+
+// withSeriesCount sets the SeriesCount of status from the most recent
+// successful training.
+func (c *Client) withSeriesCount(ctx context.Context, status JobStatus) (JobStatus, error) {
+	if status.LastTrainingStatus != JobStateSuccess {
+		return status, nil
+	}
+	trainings, err := c.JobTrainings(ctx, status.ID)
+	if err != nil {
+		return JobStatus{}, fmt.Errorf("fetching trainings: %w", err)
+	}
+	for _, t := range trainings {
+		if t.State == JobStateSuccess {
+			status.SeriesCount = t.SeriesCount
+			break
+		}
+	}
+	return status, nil
+}
+
+// WaitOptions configure WaitForTraining.
+type WaitOptions struct {
+	// PollInterval is the delay before the first poll. Defaults to 5 seconds.
+	PollInterval time.Duration
+	// MaxPollInterval caps the delay between polls, which doubles after each
+	// poll. Defaults to 1 minute.
+	MaxPollInterval time.Duration
+	// Since ignores trainings completed before this time, for example to wait
+	// for the training scheduled by an UpdateJob rather than the one before
+	// it. A failed training whose completion time is unknown is still
+	// returned, as there would be no telling when a newer one completes.
+	Since time.Time
+}
+
+// WaitForTraining polls the status of a job until its training succeeds or
+// fails, or ctx is done. A failed training is returned as a *TrainingError
+// along with the final status.
+func (c *Client) WaitForTraining(ctx context.Context, id string, opts WaitOptions) (JobStatus, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval <= 0 {
+		maxInterval = time.Minute
+	}
+
+	for {
+		status, err := c.jobStatus(ctx, id)
+		if err != nil {
+			return JobStatus{}, err
+		}
+		failed := status.LastTrainingStatus == JobStateError
+		recent := opts.Since.IsZero() ||
+			(status.LastTrainedAt != nil && status.LastTrainedAt.After(opts.Since)) ||
+			(failed && status.LastTrainedAt == nil)
+		if status.Done() && recent {
+			if failed {
+				return status, &TrainingError{Status: status}
+			}
+			return c.withSeriesCount(ctx, status)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status, ctx.Err()
+		case <-timer.C:
+		}
+		interval = min(interval*2, maxInterval)
+	}
+}
+
+// Training is a single training run of a machine learning job.
+type Training struct {
+	ID string `json:"id"`
+	// State is the outcome of the training, or its progress if it has not
+	// completed.
+	State JobState `json:"status"`
+	// ScheduledAt is when the training was scheduled.
+	ScheduledAt time.Time `json:"scheduledAt"`
+	// StartedAt is when the training started, if it has.
+	StartedAt *time.Time `json:"startedAt"`
+	// CompletedAt is when the training completed, if it has.
+	CompletedAt *time.Time `json:"completedAt"`
+	// Error is the error message of a failed training.
+	Error string `json:"error"`
+	// SeriesCount is the number of series models were produced for.
+	SeriesCount uint `json:"seriesCount"`
+}
+
+// Duration returns how long the training took, or zero if it has not
+// completed.
+func (t Training) Duration() time.Duration {
+	if t.StartedAt == nil || t.CompletedAt == nil {
+		return 0
+	}
+	return t.CompletedAt.Sub(*t.StartedAt)
+}
+
+// RetrainJob schedules an immediate training of a machine learning job without
+// changing it, for example after its source data has been corrected.
+func (c *Client) RetrainJob(ctx context.Context, id string) (Training, error) {
+	result := responseWrapper[Training]{}
+	err := c.request(ctx, "POST", path.Join(jobsPath, id, "trainings"), nil, nil, &result)
+	if err != nil {
+		return Training{}, err
+	}
+	return result.Data, nil
+}
+
+// JobTrainings fetches the history of training runs of a machine learning job,
+// most recent first.
+func (c *Client) JobTrainings(ctx context.Context, id string) ([]Training, error) {
+	result := responseWrapper[[]Training]{}
+	err := c.request(ctx, "GET", path.Join(jobsPath, id, "trainings"), nil, nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
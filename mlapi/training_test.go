@@ -0,0 +1,232 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobStatus(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/manage/api/v1/jobs/8b154ff8-3d64-4b79-8b26-02b4baeb44e4" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(
+			`{"status":"success","data":{"id":"8b154ff8-3d64-4b79-8b26-02b4baeb44e4","created":"2022-01-05T15:48:48.647Z","modified":"2022-01-05T15:48:48.647Z","createdBy":"a_user","modifiedBy":null,"name":"Test Job","metric":"test_job","description":"","grafanaUrl":"http://localhost:3000/","grafanaApiKey":"\u003credacted\u003e","datasourceId":10,"datasourceUid":"abcd1234","datasourceType":"prometheus","queryParams":{"exemplar":true,"expr":"sum(up)","interval":"","legendFormat":"","refId":"A"},"interval":300,"algorithm":"grafana_prophet_1_0_1","hyperParams":{"changepoint_prior_scale":0.05,"growth":"linear","holidays_prior_scale":10,"interval_width":0.95,"seasonality_mode":"additive","seasonality_prior_scale":10},"trainingWindow":7776000,"trainingFrequency":86400,"status":"pending","nextTrainingAt":"2022-01-05T15:48:48.638971435Z","trainingScheduledAt":null,"trainingCompletedAt":null,"lastTrainingStatus":null,"trainingResult":"Pending","trainingFailures":0,"holidays":[],"customLabels":{"test_label":"test_value"}}}`,
+		))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	status, err := c.JobStatus(ctx, "8b154ff8-3d64-4b79-8b26-02b4baeb44e4")
+	require.NoError(t, err)
+	nextTrainingAt := time.Date(2022, 1, 5, 15, 48, 48, 638971435, time.UTC)
+	assert.Equal(t, JobStatus{
+		ID:             "8b154ff8-3d64-4b79-8b26-02b4baeb44e4",
+		State:          JobStatePending,
+		NextTrainingAt: &nextTrainingAt,
+		TrainingResult: "Pending",
+	}, status)
+	assert.False(t, status.Done())
+}
+
+func TestWaitForTraining(t *testing.T) {
+	id := "8b154ff8-3d64-4b79-8b26-02b4baeb44e4"
+	for _, tc := range []struct {
+		name  string
+		final string
+		err   string
+	}{
+		{"success", `"status":"success","trainingCompletedAt":"2022-01-05T16:00:00Z","lastTrainingStatus":"success","trainingResult":"Success"`, ""},
+		{"error", `"status":"error","trainingCompletedAt":"2022-01-05T16:00:00Z","lastTrainingStatus":"error","trainingResult":"no data"`, "training of job " + id + " failed: no data"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/manage/api/v1/jobs/"+id+"/trainings" {
+					_, _ = w.Write([]byte(`{"status":"success","data":[{"id":"1","status":"success","seriesCount":2}]}`))
+					return
+				}
+				polls++
+				state := `"status":"pending","lastTrainingStatus":null`
+				if polls == 3 {
+					state = tc.final
+				}
+				_, err := w.Write([]byte(`{"status":"success","data":{"id":"` + id + `",` + state + `}}`))
+				require.NoError(t, err)
+			}))
+			defer s.Close()
+
+			c, err := New(s.URL, Config{})
+			require.NoError(t, err)
+
+			status, err := c.WaitForTraining(context.Background(), id, WaitOptions{PollInterval: time.Millisecond})
+			assert.Equal(t, 3, polls)
+			assert.True(t, status.Done())
+			if tc.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "Success", status.TrainingResult)
+				assert.Equal(t, uint(2), status.SeriesCount)
+				return
+			}
+			var trainingErr *TrainingError
+			require.True(t, errors.As(err, &trainingErr))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestWaitForTrainingSince(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"status":"success","data":{"status":"success","trainingCompletedAt":"2022-01-05T16:00:00Z","lastTrainingStatus":"success"}}`))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	// The only completed training is older than Since, so we keep polling
+	// until the context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.WaitForTraining(ctx, "8b154ff8-3d64-4b79-8b26-02b4baeb44e4", WaitOptions{
+		PollInterval: time.Millisecond,
+		Since:        time.Date(2022, 1, 6, 0, 0, 0, 0, time.UTC),
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// A failed training completed before Since is ignored too, so that
+	// waiting after fixing a job does not return the old failure.
+	oldFailure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"status":"success","data":{"id":"1","status":"error","trainingCompletedAt":"2022-01-05T16:00:00Z","lastTrainingStatus":"error","trainingResult":"no data"}}`))
+		require.NoError(t, err)
+	}))
+	defer oldFailure.Close()
+
+	c, err = New(oldFailure.URL, Config{})
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.WaitForTraining(ctx, "1", WaitOptions{
+		PollInterval: time.Millisecond,
+		Since:        time.Date(2022, 1, 6, 0, 0, 0, 0, time.UTC),
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// A failed training is returned when Since is set but the completion
+	// time is unknown.
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"status":"success","data":{"id":"1","status":"error","trainingCompletedAt":null,"lastTrainingStatus":"error","trainingResult":"no data"}}`))
+		require.NoError(t, err)
+	}))
+	defer failed.Close()
+
+	c, err = New(failed.URL, Config{})
+	require.NoError(t, err)
+	_, err = c.WaitForTraining(context.Background(), "1", WaitOptions{
+		PollInterval: time.Millisecond,
+		Since:        time.Date(2022, 1, 6, 0, 0, 0, 0, time.UTC),
+	})
+	require.EqualError(t, err, "training of job 1 failed: no data")
+}
+
+func TestJobStatusSeriesCount(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manage/api/v1/jobs/1":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"id":"1","status":"success","trainingCompletedAt":"2022-01-06T15:00:10Z","lastTrainingStatus":"success","trainingResult":"Success"}}`))
+		case "/manage/api/v1/jobs/1/trainings":
+			_, _ = w.Write([]byte(`{"status":"success","data":[` +
+				`{"id":"3","status":"pending","scheduledAt":"2022-01-07T15:00:00Z"},` +
+				`{"id":"2","status":"success","scheduledAt":"2022-01-06T15:00:00Z","completedAt":"2022-01-06T15:00:10Z","seriesCount":7},` +
+				`{"id":"1","status":"success","scheduledAt":"2022-01-05T15:00:00Z","completedAt":"2022-01-05T15:02:05Z","seriesCount":4}` +
+				`]}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	status, err := c.JobStatus(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), status.SeriesCount)
+
+	status, err = c.WaitForTraining(context.Background(), "1", WaitOptions{PollInterval: time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), status.SeriesCount)
+}
+
+func TestRetrainJob(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/manage/api/v1/jobs/8b154ff8-3d64-4b79-8b26-02b4baeb44e4/trainings" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(`{"status":"success","data":{"id":"1f3c5b1e-2d0a-4bcb-9d6e-1c9a6f3e8a10","status":"pending","scheduledAt":"2022-01-05T15:48:48Z"}}`))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	training, err := c.RetrainJob(context.Background(), "8b154ff8-3d64-4b79-8b26-02b4baeb44e4")
+	require.NoError(t, err)
+	assert.Equal(t, JobStatePending, training.State)
+	assert.Equal(t, time.Duration(0), training.Duration())
+}
+
+func TestJobTrainings(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/manage/api/v1/jobs/8b154ff8-3d64-4b79-8b26-02b4baeb44e4/trainings" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(`{"status":"success","data":[` +
+			`{"id":"2","status":"error","scheduledAt":"2022-01-06T15:00:00Z","startedAt":"2022-01-06T15:00:05Z","completedAt":"2022-01-06T15:00:10Z","error":"no data"},` +
+			`{"id":"1","status":"success","scheduledAt":"2022-01-05T15:00:00Z","startedAt":"2022-01-05T15:00:05Z","completedAt":"2022-01-05T15:02:05Z","seriesCount":4}` +
+			`]}`))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	trainings, err := c.JobTrainings(context.Background(), "8b154ff8-3d64-4b79-8b26-02b4baeb44e4")
+	require.NoError(t, err)
+	require.Len(t, trainings, 2)
+	assert.Equal(t, JobStateError, trainings[0].State)
+	assert.Equal(t, "no data", trainings[0].Error)
+	assert.Equal(t, 5*time.Second, trainings[0].Duration())
+	assert.Equal(t, JobStateSuccess, trainings[1].State)
+	assert.Equal(t, uint(4), trainings[1].SeriesCount)
+	assert.Equal(t, 2*time.Minute, trainings[1].Duration())
+}
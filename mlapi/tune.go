@@ -0,0 +1,246 @@
+package mlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// TuneMetric is the backtest metric Tune ranks candidates by. Lower is better.
+type TuneMetric string
+
+// Metrics candidates can be ranked by.
+const (
+	TuneByMAE   TuneMetric = "mae"
+	TuneByMAPE  TuneMetric = "mape"
+	TuneBySMAPE TuneMetric = "smape"
+	TuneByRMSE  TuneMetric = "rmse"
+)
+
+func (m TuneMetric) score(metrics ForecastMetrics) (float64, error) {
+	switch m {
+	case TuneByMAE:
+		return metrics.MAE, nil
+	case TuneByMAPE:
+		return metrics.MAPE, nil
+	case "", TuneBySMAPE:
+		return metrics.SMAPE, nil
+	case TuneByRMSE:
+		return metrics.RMSE, nil
+	default:
+		return 0, fmt.Errorf("unknown tune metric %q", m)
+	}
+}
+
+// SearchSpace is a set of hyperparameter values to try for one algorithm.
+type SearchSpace struct {
+	Algorithm string
+	// Params maps hyperparameter names to the values to try. Hyperparameters
+	// that are not listed use the server default.
+	Params map[string][]interface{}
+}
+
+// GridSearch returns a candidate for every combination of values in each
+// search space, in a deterministic order.
+func GridSearch(spaces ...SearchSpace) []HyperParams {
+	var candidates []HyperParams
+	for _, space := range spaces {
+		names := make([]string, 0, len(space.Params))
+		for name := range space.Params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		combos := []map[string]interface{}{{}}
+		for _, name := range names {
+			next := make([]map[string]interface{}, 0, len(combos)*len(space.Params[name]))
+			for _, combo := range combos {
+				for _, value := range space.Params[name] {
+					extended := make(map[string]interface{}, len(combo)+1)
+					for k, v := range combo {
+						extended[k] = v
+					}
+					extended[name] = value
+					next = append(next, extended)
+				}
+			}
+			combos = next
+		}
+		for _, combo := range combos {
+			candidates = append(candidates, RawHyperParams{Name: space.Algorithm, Params: combo})
+		}
+	}
+	return candidates
+}
+
+// RandomSearch returns n distinct candidates drawn at random from the grid of
+// the search spaces, or the whole grid if it has n or fewer candidates. Pass a
+// seeded rng for reproducible results.
+func RandomSearch(rng *rand.Rand, n int, spaces ...SearchSpace) []HyperParams {
+	grid := GridSearch(spaces...)
+	if n >= len(grid) {
+		return grid
+	}
+	candidates := make([]HyperParams, n)
+	for i, j := range rng.Perm(len(grid))[:n] {
+		candidates[i] = grid[j]
+	}
+	return candidates
+}
+
+// TuneOptions configure Tune.
+type TuneOptions struct {
+	// Metric is the backtest metric candidates are ranked by. Defaults to
+	// TuneBySMAPE.
+	Metric TuneMetric
+	// Concurrency is the number of candidates evaluated in parallel. Defaults
+	// to DefaultBulkConcurrency. Requests are also subject to
+	// Config.RateLimit.
+	Concurrency int
+}
+
+// TuneResult is the evaluation of one candidate.
+type TuneResult struct {
+	HyperParams HyperParams
+	// Job is the job with the candidate's algorithm and hyperparameters.
+	Job      Job
+	Backtest BacktestReport
+	// Score is the candidate's summary metric. Lower is better.
+	Score float64
+	// Err is the error evaluating the candidate, if any.
+	Err error
+}
+
+// TuneReport is the outcome of Tune.
+type TuneReport struct {
+	// Leaderboard lists the candidates from best to worst, followed by those
+	// that failed.
+	Leaderboard []TuneResult
+	// Best is the job with the best candidate, ready to pass to NewJob.
+	Best Job
+}
+
+// Tune searches for the algorithm and hyperparameters that forecast best. It
+// backtests job with each candidate over windows, and ranks the candidates by
+// the summary metric opts.Metric. See GridSearch and RandomSearch for ways to
+// build candidates.
+//
+// As in Backtest, each candidate is only trained on data before the cutoff of
+// each window, and only Prometheus queries are supported.
+//
+// A candidate fails if its hyperparameters are invalid or any of its windows
+// fail, so that every ranked candidate is scored on the same windows. The
+// actual values of each window are only queried once. Tune returns an error
+// only if every candidate fails.
+func (c *Client) Tune(ctx context.Context, job Job, windows []BacktestWindow, candidates []HyperParams, opts TuneOptions) (TuneReport, error) {
+	if len(candidates) == 0 {
+		return TuneReport{}, errors.New("no candidates to tune")
+	}
+	if err := checkBacktestJob(job); err != nil {
+		return TuneReport{}, err
+	}
+	if _, err := opts.Metric.score(ForecastMetrics{}); err != nil {
+		return TuneReport{}, err
+	}
+	actuals := memoizeActuals(c.backtestActuals(job))
+
+	results, _ := runBulk(ctx, "", "tune", candidates, BulkOptions{Concurrency: opts.Concurrency},
+		func(ctx context.Context, params HyperParams) (TuneResult, error) {
+			result := TuneResult{HyperParams: params, Job: job, Score: math.NaN()}
+			if err := result.Job.SetHyperParams(params); err != nil {
+				return result, err
+			}
+			typed, err := result.Job.TypedHyperParams()
+			if err == nil {
+				err = typed.Validate()
+			}
+			if err != nil {
+				return result, err
+			}
+
+			result.Backtest, err = runBacktest(ctx, windows, BacktestOptions{Concurrency: 1}, time.Duration(result.Job.Interval)*time.Second, c.backtestForecast(result.Job), actuals)
+			if err != nil {
+				return result, err
+			}
+			result.Score, _ = opts.Metric.score(result.Backtest.Summary)
+			if math.IsNaN(result.Score) {
+				return result, errors.New("no points to score")
+			}
+			return result, nil
+		}, nil, nil)
+
+	report := TuneReport{Leaderboard: make([]TuneResult, len(results))}
+	var errs []error
+	for i, res := range results {
+		report.Leaderboard[i] = res.Item
+		report.Leaderboard[i].HyperParams = candidates[i]
+		if res.Err != nil {
+			report.Leaderboard[i].Err = res.Err
+			errs = append(errs, fmt.Errorf("candidate %d: %w", i, res.Err))
+		}
+	}
+	sort.SliceStable(report.Leaderboard, func(a, b int) bool {
+		ra, rb := report.Leaderboard[a], report.Leaderboard[b]
+		if (ra.Err == nil) != (rb.Err == nil) {
+			return ra.Err == nil
+		}
+		return ra.Err == nil && ra.Score < rb.Score
+	})
+
+	if report.Leaderboard[0].Err != nil {
+		return report, fmt.Errorf("no candidate succeeded: %w", errors.Join(errs...))
+	}
+	report.Best = report.Leaderboard[0].Job
+	return report, nil
+}
+
+// memoizeActuals wraps actuals so that each window is only queried once,
+// however many candidates need it. Only successful queries are kept: a window
+// whose query fails, for instance because the candidate asking for it was
+// cancelled, is queried again by the next candidate, with its own context.
+// Concurrent calls for the same window wait for each other rather than
+// querying it in parallel.
+func memoizeActuals(actuals func(context.Context, BacktestWindow) (data.Frames, error)) func(context.Context, BacktestWindow) (data.Frames, error) {
+	type memo struct {
+		// lock is held while querying the window.
+		lock   chan struct{}
+		frames data.Frames
+		done   bool
+	}
+	var (
+		mu    sync.Mutex
+		memos = map[BacktestWindow]*memo{}
+	)
+	return func(ctx context.Context, w BacktestWindow) (data.Frames, error) {
+		mu.Lock()
+		m, ok := memos[w]
+		if !ok {
+			m = &memo{lock: make(chan struct{}, 1)}
+			memos[w] = m
+		}
+		mu.Unlock()
+
+		select {
+		case m.lock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-m.lock }()
+		if m.done {
+			return m.frames, nil
+		}
+		frames, err := actuals(ctx, w)
+		if err != nil {
+			return nil, err
+		}
+		m.frames, m.done = frames, true
+		return frames, nil
+	}
+}
@@ -0,0 +1,151 @@
+package mlapi
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGridSearch(t *testing.T) {
+	candidates := GridSearch(
+		SearchSpace{Algorithm: AlgorithmProphet, Params: map[string][]interface{}{
+			"seasonality_mode":        {"additive", "multiplicative"},
+			"changepoint_prior_scale": {0.01, 0.1},
+		}},
+		SearchSpace{Algorithm: "some_future_algorithm"},
+	)
+	assert.Equal(t, []HyperParams{
+		RawHyperParams{Name: AlgorithmProphet, Params: map[string]interface{}{"changepoint_prior_scale": 0.01, "seasonality_mode": "additive"}},
+		RawHyperParams{Name: AlgorithmProphet, Params: map[string]interface{}{"changepoint_prior_scale": 0.01, "seasonality_mode": "multiplicative"}},
+		RawHyperParams{Name: AlgorithmProphet, Params: map[string]interface{}{"changepoint_prior_scale": 0.1, "seasonality_mode": "additive"}},
+		RawHyperParams{Name: AlgorithmProphet, Params: map[string]interface{}{"changepoint_prior_scale": 0.1, "seasonality_mode": "multiplicative"}},
+		RawHyperParams{Name: "some_future_algorithm", Params: map[string]interface{}{}},
+	}, candidates)
+
+	space := SearchSpace{Algorithm: AlgorithmProphet, Params: map[string][]interface{}{
+		"changepoint_prior_scale": {0.001, 0.01, 0.1, 0.5},
+	}}
+	random := RandomSearch(rand.New(rand.NewPCG(1, 2)), 2, space)
+	require.Len(t, random, 2)
+	assert.NotEqual(t, random[0], random[1])
+	assert.Equal(t, random, RandomSearch(rand.New(rand.NewPCG(1, 2)), 2, space))
+	assert.Len(t, RandomSearch(rand.New(rand.NewPCG(1, 2)), 10, space), 4)
+}
+
+func TestTune(t *testing.T) {
+	// Actual values are always 10, and each candidate predicts 100 times its
+	// changepoint_prior_scale.
+	var dsQueries atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/predict/api/v1/forecast":
+			spec := ForecastRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&spec))
+			assert.Equal(t, before("cpu", spec.ForecastParams.Start), spec.Job.QueryParams["expr"])
+			yhat := 100 * spec.Job.HyperParams["changepoint_prior_scale"].(float64)
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+				data.NewFrame("",
+					data.NewField("time", nil, []time.Time{spec.ForecastParams.Start}),
+					data.NewField("yhat", nil, []float64{yhat}),
+				),
+			}}
+			_ = json.NewEncoder(w).Encode(responseWrapper[*backend.QueryDataResponse]{Data: resp})
+		case dsQueryPath:
+			dsQueries.Add(1)
+			body := struct {
+				From string `json:"from"`
+			}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			from, _ := strconv.ParseInt(body.From, 10, 64)
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+				data.NewFrame("",
+					data.NewField("Time", nil, []time.Time{time.UnixMilli(from)}),
+					data.NewField("Value", nil, []float64{10}),
+				),
+			}}
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(s.URL, Config{})
+	require.NoError(t, err)
+
+	candidates := GridSearch(SearchSpace{Algorithm: AlgorithmProphet, Params: map[string][]interface{}{
+		"changepoint_prior_scale": {0.5, 0.12, 0.05},
+	}})
+	candidates = append(candidates, RawHyperParams{Name: AlgorithmProphet, Params: map[string]interface{}{
+		"changepoint_prior_scale": 0.1, "growth": "logistic",
+	}})
+	job := prometheusJob(t, "cpu", "cpu", "prom", "cpu")
+	windows := BacktestWindows(time.Unix(3*3600, 0), time.Hour, 3)
+
+	report, err := c.Tune(context.Background(), job, windows, candidates, TuneOptions{Metric: TuneByMAE, Concurrency: 2})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, dsQueries.Load())
+
+	require.Len(t, report.Leaderboard, 4)
+	scores := []float64{}
+	for _, r := range report.Leaderboard[:3] {
+		require.NoError(t, r.Err)
+		scores = append(scores, r.Score)
+	}
+	assert.InDeltaSlice(t, []float64{2, 5, 40}, scores, 1e-9)
+	assert.ErrorContains(t, report.Leaderboard[3].Err, "growth: must be one of linear or flat")
+
+	assert.Equal(t, AlgorithmProphet, report.Best.Algorithm)
+	assert.Equal(t, map[string]interface{}{"changepoint_prior_scale": 0.12}, report.Best.HyperParams)
+	assert.Equal(t, job.QueryParams, report.Best.QueryParams)
+
+	_, err = c.Tune(context.Background(), job, windows, candidates[3:], TuneOptions{})
+	require.ErrorContains(t, err, "no candidate succeeded: candidate 0: ")
+}
+
+func TestTuneUnsupportedDatasource(t *testing.T) {
+	c, err := New("http://localhost", Config{})
+	require.NoError(t, err)
+
+	job := Job{Name: "cpu", DatasourceType: "loki", QueryParams: map[string]interface{}{"expr": "rate({app=\"a\"}[5m])"}}
+	candidates := []HyperParams{RawHyperParams{Name: AlgorithmProphet}}
+	_, err = c.Tune(context.Background(), job, BacktestWindows(time.Unix(3600, 0), time.Hour, 1), candidates, TuneOptions{})
+	require.EqualError(t, err, `backtests are only supported for prometheus queries, not "loki"`)
+}
+
+func TestMemoizeActuals(t *testing.T) {
+	var calls int
+	actuals := memoizeActuals(func(ctx context.Context, w BacktestWindow) (data.Frames, error) {
+		calls++
+		if calls == 1 {
+			return nil, context.Canceled
+		}
+		return data.Frames{data.NewFrame("actuals")}, nil
+	})
+	w := BacktestWindow{Cutoff: time.Unix(0, 0), End: time.Unix(3600, 0)}
+
+	// The query of the first candidate fails, as it was cancelled; its error
+	// is not kept for the next candidate.
+	_, err := actuals(context.Background(), w)
+	require.ErrorIs(t, err, context.Canceled)
+
+	for range 2 {
+		frames, err := actuals(context.Background(), w)
+		require.NoError(t, err)
+		assert.Len(t, frames, 1)
+	}
+	assert.Equal(t, 2, calls)
+}
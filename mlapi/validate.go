@@ -0,0 +1,60 @@
+package mlapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldError describes a problem with a single field of a resource.
+type FieldError struct {
+	// Field is the path to the invalid field, for example
+	// `trainingWindow` or `hyperParams.growth`.
+	Field string
+	// Message describes what is wrong with the field.
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors is a collection of FieldErrors found while validating a
+// resource. It is returned as an error by the Validate methods.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationErrors) add(field, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// merge adds the errors from validating a nested value, prefixing their field
+// paths with prefix.
+func (e *ValidationErrors) merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	var nested ValidationErrors
+	if !errors.As(err, &nested) {
+		e.add(prefix, "%s", err)
+		return
+	}
+	for _, fe := range nested {
+		e.add(prefix+"."+fe.Field, "%s", fe.Message)
+	}
+}
+
+// err returns e as an error, or nil if no errors were found.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}